@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ==========================================
+// 📬 INBOX API (Read-Only, used by lists.html)
+// ==========================================
+
+const (
+	DefaultPageLimit = 200
+	MaxPageLimit     = 1000
+)
+
+// Group names need a network call, so they are cached for a while
+var (
+	groupNameCache = make(map[string]groupNameEntry)
+	groupNameMutex sync.RWMutex
+)
+
+type groupNameEntry struct {
+	Name    string
+	Fetched time.Time
+}
+
+func setupInboxRoutes() {
+	http.HandleFunc("/lists", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "lists.html")
+	})
+	http.HandleFunc("/api/sessions", handleSessions)
+	http.HandleFunc("/api/chats", handleChats)
+	http.HandleFunc("/api/messages", handleMessages)
+	http.HandleFunc("/api/avatar", handleAvatar)
+	http.HandleFunc("/api/media", handleMedia)
+}
+
+// GET /api/sessions -> ["923...", ...]
+func handleSessions(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	p := principalFrom(r)
+	sm.mu.RLock()
+	ids := make([]string, 0, len(sm.Clients))
+	for id := range sm.Clients {
+		if p.CanAccessBot(id) {
+			ids = append(ids, id)
+		}
+	}
+	sm.mu.RUnlock()
+	sort.Strings(ids)
+	writeJSON(w, http.StatusOK, ids)
+}
+
+// GET /api/chats?bot_id=&before=&limit=
+func handleChats(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	client, botID, ok := requireBot(w, r, q.Get("bot_id"))
+	if !ok {
+		return
+	}
+	before, limit, ok := parsePaging(w, r)
+	if !ok {
+		return
+	}
+
+	chats, err := dataStore.ListChats(r.Context(), botID, before, limit+1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load chats")
+		return
+	}
+	if len(chats) > limit {
+		chats = chats[:limit]
+		last := chats[len(chats)-1]
+		w.Header().Set("X-Next-Cursor", Cursor{Timestamp: last.LastMessageAt, ID: last.ID}.String())
+	}
+	for i := range chats {
+		if chats[i].Name == "" {
+			chats[i].Name = resolveChatName(client, chats[i].ID)
+		}
+	}
+	writeJSON(w, http.StatusOK, chats)
+}
+
+// GET /api/messages?bot_id=&chat_id=&before=&limit=
+func handleMessages(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	_, botID, ok := requireBot(w, r, q.Get("bot_id"))
+	if !ok {
+		return
+	}
+	chatID := q.Get("chat_id")
+	if chatID == "" {
+		writeError(w, http.StatusBadRequest, "chat_id is required")
+		return
+	}
+	if _, err := types.ParseJID(chatID); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid chat_id")
+		return
+	}
+	before, limit, ok := parsePaging(w, r)
+	if !ok {
+		return
+	}
+
+	found, err := dataStore.HasChat(r.Context(), botID, chatID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load messages")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "Chat not found")
+		return
+	}
+	msgs, err := dataStore.ListMessages(r.Context(), botID, chatID, before, limit+1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load messages")
+		return
+	}
+	if len(msgs) > limit {
+		msgs = msgs[1:]
+		w.Header().Set("X-Next-Cursor", Cursor{Timestamp: msgs[0].Timestamp, ID: msgs[0].MessageID}.String())
+	}
+	writeJSON(w, http.StatusOK, msgs)
+}
+
+// GET /api/avatar?bot_id=&chat_id= -> {"url": "..."}
+func handleAvatar(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	client, _, ok := requireBot(w, r, q.Get("bot_id"))
+	if !ok {
+		return
+	}
+	jid, err := types.ParseJID(q.Get("chat_id"))
+	if err != nil || jid.IsEmpty() {
+		writeError(w, http.StatusBadRequest, "Invalid chat_id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	info, err := client.GetProfilePictureInfo(ctx, jid, &whatsmeow.GetProfilePictureParams{Preview: true})
+	if err != nil || info == nil {
+		writeError(w, http.StatusNotFound, "No profile picture")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"url": info.URL})
+}
+
+// GET /api/media?bot_id=&msg_id= -> {"content": "data:<mime>;base64,..."}
+func handleMedia(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	// Message IDs repeat across bots in the same group, so the bot is required
+	msgID, botID := q.Get("msg_id"), getCleanID(q.Get("bot_id"))
+	if msgID == "" || botID == "" {
+		writeError(w, http.StatusBadRequest, "bot_id and msg_id are required")
+		return
+	}
+	if !principalFrom(r).CanAccessBot(botID) {
+		writeError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	msg, err := dataStore.GetMessage(r.Context(), botID, msgID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load message")
+		return
+	}
+	if msg == nil || msg.raw == nil {
+		writeError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if msg.Type == "text" {
+		writeError(w, http.StatusBadRequest, "Message has no media")
+		return
+	}
+
+	sm.mu.RLock()
+	client := sm.Clients[msg.BotID]
+	sm.mu.RUnlock()
+	if client == nil {
+		writeError(w, http.StatusNotFound, "Bot not connected")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	data, err := client.DownloadAny(ctx, msg.raw)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "Media download failed: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"content": "data:" + msg.MediaMime + ";base64," + base64.StdEncoding.EncodeToString(data),
+	})
+}
+
+// ==========================================
+// 🛠️ API HELPERS
+// ==========================================
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return false
+	}
+	return true
+}
+
+// requireBot validates bot_id and returns its live client (only within the caller's tenant)
+func requireBot(w http.ResponseWriter, r *http.Request, rawID string) (*whatsmeow.Client, string, bool) {
+	if rawID == "" {
+		writeError(w, http.StatusBadRequest, "bot_id is required")
+		return nil, "", false
+	}
+	botID := getCleanID(rawID)
+	sm.mu.RLock()
+	client, ok := sm.Clients[botID]
+	sm.mu.RUnlock()
+	if !ok || !principalFrom(r).CanAccessBot(botID) {
+		writeError(w, http.StatusNotFound, "Bot not found")
+		return nil, "", false
+	}
+	return client, botID, true
+}
+
+func parsePaging(w http.ResponseWriter, r *http.Request) (Cursor, int, bool) {
+	q := r.URL.Query()
+	limit := DefaultPageLimit
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return Cursor{}, 0, false
+		}
+		if n > MaxPageLimit {
+			n = MaxPageLimit
+		}
+		limit = n
+	}
+	var before Cursor
+	if raw := q.Get("before"); raw != "" {
+		c, err := parseCursor(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid before cursor")
+			return Cursor{}, 0, false
+		}
+		before = c
+	}
+	return before, limit, true
+}
+
+func resolveChatName(client *whatsmeow.Client, chatID string) string {
+	jid, err := types.ParseJID(chatID)
+	if err != nil {
+		return ""
+	}
+
+	if jid.Server == types.GroupServer {
+		groupNameMutex.RLock()
+		entry, ok := groupNameCache[chatID]
+		groupNameMutex.RUnlock()
+		if ok && time.Since(entry.Fetched) < 10*time.Minute {
+			return entry.Name
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		info, err := client.GetGroupInfo(ctx, jid)
+		if err != nil {
+			return entry.Name
+		}
+		groupNameMutex.Lock()
+		groupNameCache[chatID] = groupNameEntry{Name: info.Name, Fetched: time.Now()}
+		groupNameMutex.Unlock()
+		return info.Name
+	}
+
+	contact, err := client.Store.Contacts.GetContact(context.Background(), jid)
+	if err != nil || !contact.Found {
+		return ""
+	}
+	if contact.FullName != "" {
+		return contact.FullName
+	}
+	return contact.PushName
+}
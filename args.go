@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 🧩 ARGUMENT PARSER
+// ==========================================
+
+// Commands may declare Args/Flags; dispatchCommand then parses the input
+// into typed values (c.Str, c.Int, c.JID ...) and answers bad input with a
+// usage line generated from the same spec.
+
+type ArgType int
+
+const (
+	ArgString   ArgType = iota // one word or "quoted text"
+	ArgText                    // rest of the line, newlines kept
+	ArgNumber                  // whole number
+	ArgDuration                // 30s, 10m, 2h, 1d, 1w
+	ArgURL                     // http(s) link
+	ArgJID                     // @mention, number or the replied-to user
+)
+
+type ArgSpec struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+	Choices  []string // allowed values for ArgString (lower case)
+}
+
+type FlagSpec struct {
+	Name  string // --name
+	Short string // -n
+	Type  ArgType
+	Bool  bool // switch without a value
+}
+
+// token is one word of input with its position in the raw text
+type token struct {
+	Value      string
+	Start, End int
+	Quoted     bool
+}
+
+// tokenize splits on whitespace, honouring "double", 'single' and “smart” quotes
+func tokenize(s string) []token {
+	var out []token
+	runes := []rune(s)
+	offsets := make([]int, len(runes)+1) // rune index -> byte offset
+	for i, pos := 0, 0; i < len(runes); i++ {
+		offsets[i] = pos
+		pos += len(string(runes[i]))
+	}
+	offsets[len(runes)] = len(s)
+
+	closing := map[rune]rune{'"': '"', '\'': '\'', '“': '”'}
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		var sb strings.Builder
+		quoted := false
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if end, ok := closing[runes[i]]; ok && (i == start || quoted) {
+				quoted = true
+				i++
+				for i < len(runes) && runes[i] != end {
+					if runes[i] == '\\' && end == '"' && i+1 < len(runes) {
+						i++
+					}
+					sb.WriteRune(runes[i])
+					i++
+				}
+				i++ // closing quote (or end of input)
+				continue
+			}
+			sb.WriteRune(runes[i])
+			i++
+		}
+		if i > len(runes) {
+			i = len(runes)
+		}
+		out = append(out, token{Value: sb.String(), Start: offsets[start], End: offsets[i], Quoted: quoted})
+	}
+	return out
+}
+
+// ParsedArgs holds the typed values of one invocation
+type ParsedArgs struct {
+	Values map[string]interface{}
+}
+
+// parseArgs fills cmd's spec from the raw argument text
+func parseArgs(raw string, v *events.Message, cmd *Command) (*ParsedArgs, error) {
+	p := &ParsedArgs{Values: make(map[string]interface{})}
+	tokens := tokenize(raw)
+
+	// 1. Flags (anywhere before a rest-of-line argument or "--")
+	var positional []token
+	textStarted := false
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		// Once the next argument is rest-of-line text, even "-word" belongs to it
+		textStarted = textStarted || nextIsText(cmd, len(positional)+1)
+		if textStarted || len(cmd.Flags) == 0 || t.Quoted || !strings.HasPrefix(t.Value, "-") || len(t.Value) < 2 || isNumber(t.Value) {
+			positional = append(positional, t)
+			continue
+		}
+		if t.Value == "--" {
+			positional = append(positional, tokens[i+1:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(t.Value, "-"), "=")
+		f := findFlag(cmd, name, strings.HasPrefix(t.Value, "--"))
+		if f == nil {
+			return nil, fmt.Errorf("unknown option %s", t.Value)
+		}
+		if f.Bool {
+			p.Values[f.Name] = true
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("option --%s needs a value", f.Name)
+			}
+			i++
+			value = tokens[i].Value
+		}
+		val, err := convertArg(f.Type, value, v)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %v", f.Name, err)
+		}
+		p.Values[f.Name] = val
+	}
+
+	// 2. Positional arguments in declaration order
+	pos := 0
+	for _, spec := range cmd.Args {
+		if spec.Type == ArgText {
+			if pos < len(positional) {
+				text := strings.TrimSpace(raw[positional[pos].Start:])
+				if pos == len(positional)-1 && positional[pos].Quoted {
+					text = positional[pos].Value
+				}
+				p.Values[spec.Name] = text
+				pos = len(positional)
+			} else if !spec.Optional {
+				return nil, fmt.Errorf("missing %s", spec.Name)
+			}
+			continue
+		}
+
+		// The replied-to user stands in for a JID that was not typed
+		if spec.Type == ArgJID && (pos >= len(positional) || !looksLikeJID(positional[pos].Value)) {
+			if jid, ok := repliedUser(v); ok {
+				p.Values[spec.Name] = jid
+				continue
+			}
+			if spec.Optional {
+				continue
+			}
+		}
+		if pos >= len(positional) {
+			if !spec.Optional {
+				return nil, fmt.Errorf("missing %s", spec.Name)
+			}
+			continue
+		}
+
+		value := positional[pos].Value
+		if len(spec.Choices) > 0 {
+			value = strings.ToLower(value)
+			if !containsString(spec.Choices, value) {
+				if spec.Optional {
+					continue // leave the word for the next argument
+				}
+				return nil, fmt.Errorf("%s must be one of: %s", spec.Name, strings.Join(spec.Choices, ", "))
+			}
+		}
+		val, err := convertArg(spec.Type, value, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", spec.Name, err)
+		}
+		p.Values[spec.Name] = val
+		pos++
+	}
+	if pos < len(positional) {
+		return nil, fmt.Errorf("unexpected argument %q", positional[pos].Value)
+	}
+	return p, nil
+}
+
+// nextIsText: the n-th positional argument starts rest-of-line text
+func nextIsText(cmd *Command, n int) bool {
+	return n <= len(cmd.Args) && cmd.Args[n-1].Type == ArgText
+}
+
+func findFlag(cmd *Command, name string, long bool) *FlagSpec {
+	for i := range cmd.Flags {
+		f := &cmd.Flags[i]
+		if (long && f.Name == name) || (!long && f.Short == name) {
+			return f
+		}
+	}
+	return nil
+}
+
+var durationRe = regexp.MustCompile(`^(\d+)\s*(s|sec|m|min|h|hr|d|day|days|w|week|weeks)$`)
+
+func convertArg(t ArgType, value string, v *events.Message) (interface{}, error) {
+	switch t {
+	case ArgNumber:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return n, nil
+	case ArgDuration:
+		return parseDuration(value)
+	case ArgURL:
+		if !strings.Contains(value, "://") && strings.HasPrefix(value, "www.") {
+			value = "https://" + value
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%q is not a valid link", value)
+		}
+		return u.String(), nil
+	case ArgJID:
+		if jid, ok := jidFromArg(value, v); ok {
+			return jid, nil
+		}
+		return nil, fmt.Errorf("%q is not a user", value)
+	}
+	return value, nil
+}
+
+// parseDuration accepts Go durations plus d/w units
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if m := durationRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[m[2][0]]
+		return time.Duration(n) * unit, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q is not a duration (e.g. 10m, 2h, 1d)", s)
+	}
+	return d, nil
+}
+
+// jidFromArg matches "@123" against the message mentions, else treats it as a number
+func jidFromArg(value string, v *events.Message) (types.JID, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' { return r }
+		return -1
+	}, value)
+	if digits == "" {
+		return types.EmptyJID, false
+	}
+	for _, m := range v.Message.GetExtendedTextMessage().GetContextInfo().GetMentionedJID() {
+		if jid, err := types.ParseJID(m); err == nil && jid.User == digits {
+			return jid, true
+		}
+	}
+	if len(digits) < 7 {
+		return types.EmptyJID, false
+	}
+	return types.NewJID(digits, types.DefaultUserServer), true
+}
+
+func looksLikeJID(value string) bool {
+	return strings.HasPrefix(value, "@") || strings.HasPrefix(value, "+") || isNumber(value)
+}
+
+func repliedUser(v *events.Message) (types.JID, bool) {
+	p := v.Message.GetExtendedTextMessage().GetContextInfo().GetParticipant()
+	if p == "" {
+		return types.EmptyJID, false
+	}
+	jid, err := types.ParseJID(p)
+	return jid, err == nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s { return true }
+	}
+	return false
+}
+
+// usageOf returns the hand-written usage or builds one from the spec
+func usageOf(cmd *Command) string {
+	if cmd.Usage != "" || (len(cmd.Args) == 0 && len(cmd.Flags) == 0) {
+		return cmd.Usage
+	}
+	label := map[ArgType]string{ArgNumber: "number", ArgDuration: "duration", ArgURL: "link", ArgJID: "@user|number"}
+	var parts []string
+	for _, a := range cmd.Args {
+		s := a.Name
+		if len(a.Choices) > 0 {
+			s = strings.Join(a.Choices, "|")
+		} else if l, ok := label[a.Type]; ok && l != a.Name {
+			s += ":" + l
+		}
+		if a.Type == ArgText { s += "..." }
+		if a.Optional {
+			parts = append(parts, "["+s+"]")
+		} else {
+			parts = append(parts, "<"+s+">")
+		}
+	}
+	for _, f := range cmd.Flags {
+		s := "--" + f.Name
+		if f.Short != "" { s = "-" + f.Short + "|" + s }
+		if !f.Bool {
+			l := label[f.Type]
+			if l == "" { l = "value" }
+			s += " <" + l + ">"
+		}
+		parts = append(parts, "["+s+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ==========================================
+// 🔎 TYPED ACCESSORS (CmdContext)
+// ==========================================
+
+func (c *CmdContext) value(name string) interface{} {
+	if c.Parsed == nil {
+		return nil
+	}
+	return c.Parsed.Values[name]
+}
+
+// Has reports whether an argument or flag was given
+func (c *CmdContext) Has(name string) bool { return c.value(name) != nil }
+
+func (c *CmdContext) Str(name string) string {
+	s, _ := c.value(name).(string)
+	return s
+}
+
+func (c *CmdContext) Int(name string) int {
+	n, _ := c.value(name).(int)
+	return n
+}
+
+func (c *CmdContext) Duration(name string) time.Duration {
+	d, _ := c.value(name).(time.Duration)
+	return d
+}
+
+func (c *CmdContext) JID(name string) types.JID {
+	j, _ := c.value(name).(types.JID)
+	return j
+}
+
+func (c *CmdContext) Bool(name string) bool {
+	b, _ := c.value(name).(bool)
+	return b
+}
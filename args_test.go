@@ -0,0 +1,151 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   []string
+		quoted []bool
+	}{
+		{in: "", want: nil},
+		{in: "  a b\t\tc \n", want: []string{"a", "b", "c"}, quoted: []bool{false, false, false}},
+		{in: `"hello world" x`, want: []string{"hello world", "x"}, quoted: []bool{true, false}},
+		{in: `'single' “smart quotes”`, want: []string{"single", "smart quotes"}, quoted: []bool{true, true}},
+		{in: `say "a \"b\""`, want: []string{"say", `a "b"`}, quoted: []bool{false, true}},
+		{in: `"unterminated text`, want: []string{"unterminated text"}, quoted: []bool{true}},
+		{in: `don't stop`, want: []string{"don't", "stop"}, quoted: []bool{false, false}},
+		{in: "-5 --flag", want: []string{"-5", "--flag"}, quoted: []bool{false, false}},
+	}
+	for _, tt := range tests {
+		got := tokenize(tt.in)
+		var values []string
+		var quoted []bool
+		for _, tok := range got {
+			values = append(values, tok.Value)
+			quoted = append(quoted, tok.Quoted)
+		}
+		if !reflect.DeepEqual(values, tt.want) || (tt.quoted != nil && !reflect.DeepEqual(quoted, tt.quoted)) {
+			t.Errorf("tokenize(%q) = %q quoted=%v, want %q quoted=%v", tt.in, values, quoted, tt.want, tt.quoted)
+		}
+	}
+}
+
+// Offsets are byte positions, so raw[Start:] works on multi-byte text
+func TestTokenizeOffsets(t *testing.T) {
+	in := `ß "ü x" y`
+	got := tokenize(in)
+	want := [][2]int{{0, 2}, {3, 9}, {10, 11}}
+	if len(got) != len(want) {
+		t.Fatalf("tokenize(%q) gave %d tokens, want %d", in, len(got), len(want))
+	}
+	for i, tok := range got {
+		if tok.Start != want[i][0] || tok.End != want[i][1] {
+			t.Errorf("token %d (%q) at [%d:%d], want [%d:%d]", i, tok.Value, tok.Start, tok.End, want[i][0], want[i][1])
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tagall := &Command{Name: "tagall", Args: []ArgSpec{{Name: "text", Type: ArgText, Optional: true}}}
+	tr := &Command{Name: "tr", Args: []ArgSpec{{Name: "lang"}, {Name: "text", Type: ArgText}}}
+	prefix := &Command{Name: "prefix",
+		Args: []ArgSpec{
+			{Name: "action", Choices: []string{"list", "add", "del"}, Optional: true},
+			{Name: "value", Optional: true},
+			{Name: "extra", Optional: true},
+		},
+		Flags: []FlagSpec{{Name: "here", Short: "h", Bool: true}},
+	}
+	mute := &Command{Name: "mute",
+		Args:  []ArgSpec{{Name: "user", Type: ArgJID}, {Name: "time", Type: ArgDuration, Optional: true}},
+		Flags: []FlagSpec{{Name: "reason", Short: "r", Type: ArgString}},
+	}
+	count := &Command{Name: "count", Args: []ArgSpec{{Name: "n", Type: ArgNumber}}}
+	link := &Command{Name: "yt", Args: []ArgSpec{{Name: "link", Type: ArgURL}}}
+
+	user := types.NewJID("923001234567", types.DefaultUserServer)
+	plain := &events.Message{Message: &waProto.Message{}}
+	reply := &events.Message{Message: &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+		ContextInfo: &waProto.ContextInfo{Participant: proto.String(user.String())},
+	}}}
+
+	tests := []struct {
+		name    string
+		cmd     *Command
+		raw     string
+		msg     *events.Message
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "text may start with a dash", cmd: tagall, raw: "-urgent meeting",
+			want: map[string]interface{}{"text": "-urgent meeting"}},
+		{name: "text after a word may start with --", cmd: tr, raw: "en --hi there",
+			want: map[string]interface{}{"lang": "en", "text": "--hi there"}},
+		{name: "text keeps newlines", cmd: tagall, raw: "line one\nline two",
+			want: map[string]interface{}{"text": "line one\nline two"}},
+		{name: "single quoted text is unquoted", cmd: tr, raw: `ur "good morning"`,
+			want: map[string]interface{}{"lang": "ur", "text": "good morning"}},
+		{name: "missing text", cmd: tr, raw: "en", wantErr: true},
+		{name: "empty optional text", cmd: tagall, raw: "", want: map[string]interface{}{}},
+
+		{name: "long bool flag", cmd: prefix, raw: "add ! --here",
+			want: map[string]interface{}{"action": "add", "value": "!", "here": true}},
+		{name: "short bool flag first", cmd: prefix, raw: "-h del !",
+			want: map[string]interface{}{"action": "del", "value": "!", "here": true}},
+		{name: "choices are case-insensitive", cmd: prefix, raw: "ADD x",
+			want: map[string]interface{}{"action": "add", "value": "x"}},
+		{name: "double dash ends flags", cmd: prefix, raw: "add -- --here",
+			want: map[string]interface{}{"action": "add", "value": "--here"}},
+		{name: "unknown flag", cmd: prefix, raw: "--bogus", wantErr: true},
+		{name: "too many arguments", cmd: prefix, raw: "add a b c", wantErr: true},
+
+		{name: "typed number as user", cmd: mute, raw: "@923001234567 10m",
+			want: map[string]interface{}{"user": user, "time": 10 * time.Minute}},
+		{name: "replied user fills the JID", cmd: mute, raw: "2h", msg: reply,
+			want: map[string]interface{}{"user": user, "time": 2 * time.Hour}},
+		{name: "missing user", cmd: mute, raw: "", wantErr: true},
+		{name: "flag with = value", cmd: mute, raw: "923001234567 --reason=spam",
+			want: map[string]interface{}{"user": user, "reason": "spam"}},
+		{name: "short flag with quoted value", cmd: mute, raw: `923001234567 -r "too many links" 1d`,
+			want: map[string]interface{}{"user": user, "reason": "too many links", "time": 24 * time.Hour}},
+		{name: "flag without value", cmd: mute, raw: "923001234567 --reason", wantErr: true},
+		{name: "bad duration", cmd: mute, raw: "923001234567 soon", wantErr: true},
+
+		{name: "negative number is not a flag", cmd: count, raw: "-5", want: map[string]interface{}{"n": -5}},
+		{name: "not a number", cmd: count, raw: "five", wantErr: true},
+		{name: "www link gets a scheme", cmd: link, raw: "www.example.com/v",
+			want: map[string]interface{}{"link": "https://www.example.com/v"}},
+		{name: "non-http link", cmd: link, raw: "ftp://example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.msg
+			if msg == nil {
+				msg = plain
+			}
+			got, err := parseArgs(tt.raw, msg, tt.cmd)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseArgs(%q) = %v, want an error", tt.raw, got.Values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs(%q): %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("parseArgs(%q) = %#v, want %#v", tt.raw, got.Values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ==========================================
+// 🔐 AUTH (dashboard login, bearer tokens, CSRF)
+// ==========================================
+
+// Config (env):
+//
+//	ADMIN_USER           admin username (default "admin")
+//	ADMIN_PASSWORD_HASH  bcrypt hash for ADMIN_USER
+//	ADMIN_PASSWORD       plain password, hashed on startup (if no hash given)
+//	ADMIN_TOKEN          static bearer token for scripts (acts as ADMIN_USER)
+//	ALLOWED_ORIGINS      extra origins allowed for cookies/WS, comma separated
+const (
+	SessionCookie  = "sb_session"
+	CSRFCookie     = "sb_csrf"
+	CSRFHeader     = "X-CSRF-Token"
+	AuthSessionTTL = 7 * 24 * time.Hour
+
+	bucketAuthUsers    = "auth_users"
+	bucketAuthSessions = "auth_sessions"
+	bucketAuthTokens   = "auth_tokens"
+
+	// Login throttling per client IP
+	LoginMaxFailures = 10
+	LoginLockWindow  = 15 * time.Minute
+)
+
+type AuthUser struct {
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	Tenant       string    `json:"tenant,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (u AuthUser) tenant() string {
+	if u.Tenant == "" {
+		return DefaultTenant
+	}
+	return u.Tenant
+}
+
+type authSession struct {
+	Username  string    `json:"username"`
+	CSRF      string    `json:"csrf"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// apiToken is stored under sha256(token); the token itself is shown only once
+type apiToken struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Principal: جو بھی request کر رہا ہے
+type Principal struct {
+	Username string `json:"username"`
+	Tenant   string `json:"tenant"`
+	Admin    bool   `json:"admin"` // env admin: sees every tenant
+	Via      string `json:"via"`   // "session" | "token"
+	csrf     string
+}
+
+type principalKey struct{}
+
+var (
+	adminUser      string
+	adminTokenHash []byte
+	allowedOrigins = make(map[string]bool)
+
+	loginFailures = make(map[string][]time.Time)
+	loginMu       sync.Mutex
+)
+
+// Paths reachable without logging in
+var publicPaths = map[string]bool{
+	"/login":          true,
+	"/pic.png":        true,
+	"/api/auth/login": true,
+}
+
+func InitAuth() {
+	ctx := context.Background()
+	adminUser = strings.TrimSpace(os.Getenv("ADMIN_USER"))
+	if adminUser == "" {
+		adminUser = "admin"
+	}
+	if tok := os.Getenv("ADMIN_TOKEN"); tok != "" {
+		sum := sha256.Sum256([]byte(tok))
+		adminTokenHash = sum[:]
+	}
+	for _, o := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			allowedOrigins[strings.ToLower(o)] = true
+		}
+	}
+
+	hash := os.Getenv("ADMIN_PASSWORD_HASH")
+	if hash == "" {
+		if pw := os.Getenv("ADMIN_PASSWORD"); pw != "" {
+			b, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+			if err != nil {
+				fmt.Printf("⚠️ Password hashing failed: %v\n", err)
+			}
+			hash = string(b)
+		}
+	}
+
+	var existing AuthUser
+	found, _ := getJSON(bucketAuthUsers, adminUser, &existing)
+	switch {
+	case hash != "":
+		existing = AuthUser{Username: adminUser, PasswordHash: hash, CreatedAt: time.Now()}
+		if err := putJSON(bucketAuthUsers, adminUser, existing); err != nil {
+			fmt.Printf("⚠️ Failed to store admin user: %v\n", err)
+		}
+	case !found:
+		// Never start open: generate a password and print it once
+		pw := randomToken(12)
+		b, _ := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+		putJSON(bucketAuthUsers, adminUser, AuthUser{Username: adminUser, PasswordHash: string(b), CreatedAt: time.Now()})
+		fmt.Printf("🔑 Generated dashboard login -> user: %s  password: %s (set ADMIN_PASSWORD to change)\n", adminUser, pw)
+	}
+
+	go authSweepLoop(ctx)
+	fmt.Println("🔐 Auth enabled.")
+}
+
+func setupAuthRoutes() {
+	http.HandleFunc("GET /login", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "login.html")
+	})
+	http.HandleFunc("POST /api/auth/login", handleLogin)
+	http.HandleFunc("POST /api/auth/logout", handleLogout)
+	http.HandleFunc("GET /api/auth/me", handleMe)
+	http.HandleFunc("GET /api/auth/tokens", handleListTokens)
+	http.HandleFunc("POST /api/auth/tokens", handleCreateToken)
+	http.HandleFunc("DELETE /api/auth/tokens/{id}", handleDeleteToken)
+}
+
+// ==========================================
+// 🧱 MIDDLEWARE
+// ==========================================
+
+// withAuth guards every route except publicPaths
+func withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p := authenticate(r)
+		if p == nil {
+			if isAPIPath(r.URL.Path) {
+				writeError(w, http.StatusUnauthorized, "Login required")
+				return
+			}
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		// Cookie sessions must prove the request came from our own pages
+		if p.Via == "session" && !isSafeMethod(r.Method) {
+			if !sameOrigin(r) || !validCSRF(r, p.csrf) {
+				writeError(w, http.StatusForbidden, "CSRF check failed")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// principalFrom returns the caller set by withAuth (nil outside it)
+func principalFrom(r *http.Request) *Principal {
+	p, _ := r.Context().Value(principalKey{}).(*Principal)
+	return p
+}
+
+func authenticate(r *http.Request) *Principal {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return authenticateToken(strings.TrimSpace(strings.TrimPrefix(h, "Bearer ")))
+	}
+	c, err := r.Cookie(SessionCookie)
+	if err != nil || c.Value == "" {
+		return nil
+	}
+	var s authSession
+	key := hashToken(c.Value)
+	if ok, _ := getJSON(bucketAuthSessions, key, &s); !ok {
+		return nil
+	}
+	if time.Now().After(s.ExpiresAt) {
+		dataStore.Delete(r.Context(), bucketAuthSessions, key)
+		return nil
+	}
+	p := principalFor(s.Username, "session")
+	if p != nil {
+		p.csrf = s.CSRF
+	}
+	return p
+}
+
+// principalFor loads the account so deleted users lose access immediately
+func principalFor(username, via string) *Principal {
+	var u AuthUser
+	if ok, _ := getJSON(bucketAuthUsers, username, &u); !ok {
+		return nil
+	}
+	return &Principal{Username: u.Username, Tenant: u.tenant(), Admin: u.Username == adminUser, Via: via}
+}
+
+func authenticateToken(tok string) *Principal {
+	if tok == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(tok))
+	if adminTokenHash != nil && subtle.ConstantTimeCompare(sum[:], adminTokenHash) == 1 {
+		return &Principal{Username: adminUser, Tenant: DefaultTenant, Admin: true, Via: "token"}
+	}
+	var t apiToken
+	if ok, _ := getJSON(bucketAuthTokens, hex.EncodeToString(sum[:]), &t); !ok {
+		return nil
+	}
+	return principalFor(t.Username, "token")
+}
+
+// validCSRF: double-submit, header must match both the cookie and the session
+func validCSRF(r *http.Request, expected string) bool {
+	header := r.Header.Get(CSRFHeader)
+	c, err := r.Cookie(CSRFCookie)
+	if header == "" || err != nil || expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header), []byte(c.Value)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(header), []byte(expected)) == 1
+}
+
+// sameOrigin rejects cross-site Origin/Referer (missing headers are allowed, CSRF token still applies)
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		if ref := r.Header.Get("Referer"); ref != "" {
+			if u, err := url.Parse(ref); err == nil {
+				origin = u.Scheme + "://" + u.Host
+			}
+		}
+	}
+	return origin == "" || originAllowed(r, origin)
+}
+
+func originAllowed(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return allowedOrigins[strings.ToLower(strings.TrimRight(origin, "/"))]
+}
+
+// checkWSOrigin: browsers always send Origin on WS, so it must be ours.
+// Scripts using a bearer token may omit it.
+func checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		p := principalFrom(r)
+		return p != nil && p.Via == "token"
+	}
+	return originAllowed(r, origin)
+}
+
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/ws"
+}
+
+func isSafeMethod(m string) bool {
+	return m == http.MethodGet || m == http.MethodHead || m == http.MethodOptions
+}
+
+// ==========================================
+// 🌐 AUTH HANDLERS
+// ==========================================
+
+// POST /api/auth/login {"username","password"} -> sets session + CSRF cookies
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		writeError(w, http.StatusForbidden, "Cross-site login blocked")
+		return
+	}
+	ip := clientIP(r)
+	if loginLocked(ip) {
+		writeError(w, http.StatusTooManyRequests, "Too many failed attempts, try again later")
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	var u AuthUser
+	found, _ := getJSON(bucketAuthUsers, strings.TrimSpace(req.Username), &u)
+	if !found || bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)) != nil {
+		recordLoginFailure(ip)
+		writeError(w, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+	clearLoginFailures(ip)
+
+	token, csrf := randomToken(32), randomToken(32)
+	s := authSession{Username: u.Username, CSRF: csrf, ExpiresAt: time.Now().Add(AuthSessionTTL)}
+	if err := putJSON(bucketAuthSessions, hashToken(token), s); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	secure := r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+	http.SetCookie(w, &http.Cookie{
+		Name: SessionCookie, Value: token, Path: "/", Expires: s.ExpiresAt,
+		HttpOnly: true, Secure: secure, SameSite: http.SameSiteLaxMode,
+	})
+	// Readable by JS so pages can echo it in X-CSRF-Token
+	http.SetCookie(w, &http.Cookie{
+		Name: CSRFCookie, Value: csrf, Path: "/", Expires: s.ExpiresAt,
+		Secure: secure, SameSite: http.SameSiteStrictMode,
+	})
+	fmt.Printf("🔓 Dashboard login: %s (%s)\n", u.Username, ip)
+	writeJSON(w, http.StatusOK, map[string]string{"username": u.Username, "csrf_token": csrf})
+}
+
+// POST /api/auth/logout
+func handleLogout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(SessionCookie); err == nil {
+		dataStore.Delete(r.Context(), bucketAuthSessions, hashToken(c.Value))
+	}
+	for _, name := range []string{SessionCookie, CSRFCookie} {
+		http.SetCookie(w, &http.Cookie{Name: name, Value: "", Path: "/", MaxAge: -1})
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// GET /api/auth/me
+func handleMe(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, principalFrom(r))
+}
+
+// GET /api/auth/tokens -> tokens of the caller (without secrets)
+func handleListTokens(w http.ResponseWriter, r *http.Request) {
+	p := principalFrom(r)
+	all, err := dataStore.List(r.Context(), bucketAuthTokens)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load tokens")
+		return
+	}
+	out := []apiToken{}
+	for _, raw := range all {
+		var t apiToken
+		if json.Unmarshal(raw, &t) == nil && t.Username == p.Username {
+			out = append(out, t)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// POST /api/auth/tokens {"name"} -> {"token"} (shown once)
+func handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req)
+
+	tok := "sb_" + randomToken(32)
+	key := hashToken(tok)
+	t := apiToken{ID: key[:12], Name: strings.TrimSpace(req.Name), Username: principalFrom(r).Username, CreatedAt: time.Now()}
+	if err := putJSON(bucketAuthTokens, key, t); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"token": tok, "info": t})
+}
+
+// DELETE /api/auth/tokens/{id}
+func handleDeleteToken(w http.ResponseWriter, r *http.Request) {
+	p := principalFrom(r)
+	id := r.PathValue("id")
+	all, err := dataStore.List(r.Context(), bucketAuthTokens)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load tokens")
+		return
+	}
+	for key, raw := range all {
+		var t apiToken
+		if json.Unmarshal(raw, &t) == nil && t.ID == id && t.Username == p.Username {
+			dataStore.Delete(r.Context(), bucketAuthTokens, key)
+			writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Unknown token")
+}
+
+// ==========================================
+// 🧰 HELPERS
+// ==========================================
+
+func randomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func hashToken(tok string) string {
+	sum := sha256.Sum256([]byte(tok))
+	return hex.EncodeToString(sum[:])
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func loginLocked(ip string) bool {
+	loginMu.Lock()
+	defer loginMu.Unlock()
+	cutoff := time.Now().Add(-LoginLockWindow)
+	recent := loginFailures[ip][:0]
+	for _, t := range loginFailures[ip] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	loginFailures[ip] = recent
+	return len(recent) >= LoginMaxFailures
+}
+
+func recordLoginFailure(ip string) {
+	loginMu.Lock()
+	loginFailures[ip] = append(loginFailures[ip], time.Now())
+	loginMu.Unlock()
+}
+
+func clearLoginFailures(ip string) {
+	loginMu.Lock()
+	delete(loginFailures, ip)
+	loginMu.Unlock()
+}
+
+// authSweepLoop removes expired dashboard sessions
+func authSweepLoop(ctx context.Context) {
+	for range time.Tick(1 * time.Hour) {
+		all, err := dataStore.List(ctx, bucketAuthSessions)
+		if err != nil {
+			continue
+		}
+		for key, raw := range all {
+			var s authSession
+			if json.Unmarshal(raw, &s) != nil || time.Now().After(s.ExpiresAt) {
+				dataStore.Delete(ctx, bucketAuthSessions, key)
+			}
+		}
+	}
+}
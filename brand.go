@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// ==========================================
+// 🎨 MENU BRANDING (ہر بوٹ کا اپنا انداز)
+// ==========================================
+
+// Branding lives in BotSettings; empty fields fall back to the defaults below.
+// The custom menu image itself is stored in bucketMenuImages, keyed by bot.
+type Branding struct {
+	Name           string `json:"name,omitempty"`
+	OwnerLabel     string `json:"owner_label,omitempty"`
+	Footer         string `json:"footer,omitempty"`
+	ImageHash      string `json:"image_hash,omitempty"`     // sha256 of the stored image; empty = pic.png
+	NewsletterJID  string `json:"newsletter_jid,omitempty"` // "off" hides the channel badge
+	NewsletterName string `json:"newsletter_name,omitempty"`
+	Template       string `json:"template,omitempty"`
+}
+
+const (
+	bucketMenuImages = "menu_images"
+	MaxMenuImageSize = 5 << 20
+	MenuImageTTL     = 7 * 24 * time.Hour // used when the media URL carries no expiry
+	NewsletterOff    = "off"
+
+	DefaultNewsletterJID  = "120363424476167116@newsletter"
+	DefaultNewsletterName = "Silent Hackers Official"
+	DefaultMenuFooter     = "💖 𝙎𝙞𝙡𝙚𝙣𝙩 𝙃𝙖𝙘𝙠𝙚𝙧𝙨 💖"
+)
+
+// Placeholders: {name} {owner} {mode} {uptime} {page} {pages} {prefix} {commands} {hint} {footer}
+const DefaultMenuTemplate = `
+      ｡ﾟﾟ･｡･ﾟﾟ｡
+      ﾟ。    {name}
+      　ﾟ･｡･ﾟ
+
+ 👑 𝐎𝐰𝐧𝐞𝐫 : {owner}
+ 🛡️ 𝐌𝐨𝐝𝐞 : {mode}
+ ⏳ 𝐔𝐩𝐭𝐢𝐦𝐞 : {uptime}
+ 📄 𝐏𝐚𝐠𝐞 : {page}/{pages}
+
+   ⋆ 🎀 ⋆ ──── ⋆ 🎀 ⋆
+
+{commands}{hint}
+
+      {footer}
+`
+
+// botBranding returns the stored branding of botID as is
+func botBranding(botID string) Branding {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if s := sm.Settings[botID]; s != nil {
+		return s.Branding
+	}
+	return Branding{}
+}
+
+// effectiveBranding fills every empty field with the default
+func effectiveBranding(botID string) Branding {
+	b := botBranding(botID)
+	if b.Name == "" { b.Name = BOT_NAME }
+	if b.OwnerLabel == "" { b.OwnerLabel = OWNER_NAME }
+	if b.Footer == "" { b.Footer = DefaultMenuFooter }
+	if b.Template == "" { b.Template = DefaultMenuTemplate }
+	if b.NewsletterJID == "" {
+		b.NewsletterJID, b.NewsletterName = DefaultNewsletterJID, DefaultNewsletterName
+	}
+	if b.NewsletterName == "" { b.NewsletterName = b.Name }
+	return b
+}
+
+func updateBranding(botID string, fn func(b *Branding)) {
+	sm.mu.Lock()
+	if sm.Settings[botID] == nil { sm.Settings[botID] = &BotSettings{Prefix: ".", AlwaysOnline: true, Mode: "public"} }
+	fn(&sm.Settings[botID].Branding)
+	sm.mu.Unlock()
+	saveSettings()
+}
+
+// renderMenu fills the template placeholders
+func renderMenu(b Branding, vars map[string]string) string {
+	pairs := []string{"{name}", b.Name, "{owner}", b.OwnerLabel, "{footer}", b.Footer}
+	for k, v := range vars {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(b.Template)
+}
+
+// newsletterContext adds the "forwarded from channel" badge unless it is turned off
+func newsletterContext(b Branding, ctx *waProto.ContextInfo) {
+	if b.NewsletterJID == NewsletterOff {
+		return
+	}
+	ctx.IsForwarded = proto.Bool(true)
+	ctx.ForwardedNewsletterMessageInfo = &waProto.ForwardedNewsletterMessageInfo{
+		NewsletterJID:   proto.String(b.NewsletterJID),
+		NewsletterName:  proto.String(b.NewsletterName),
+		ServerMessageID: proto.Int32(100),
+	}
+}
+
+// ==========================================
+// 🖼️ MENU IMAGE (per-bot upload cache)
+// ==========================================
+
+type menuImageEntry struct {
+	Hash    string
+	Msg     *waProto.ImageMessage
+	Expires time.Time
+}
+
+var (
+	menuImageCache = make(map[string]*menuImageEntry)
+	menuImageMutex sync.Mutex
+)
+
+// menuImage returns an uploaded copy of the bot's menu image, uploading again
+// when the image changed or the previous upload is about to expire
+func menuImage(client *whatsmeow.Client, botID string, b Branding) (*waProto.ImageMessage, error) {
+	key := b.ImageHash
+	if key == "" { key = "pic.png" }
+
+	menuImageMutex.Lock()
+	entry := menuImageCache[botID]
+	menuImageMutex.Unlock()
+	if entry != nil && entry.Hash == key && time.Now().Before(entry.Expires) {
+		return proto.Clone(entry.Msg).(*waProto.ImageMessage), nil
+	}
+
+	var data []byte
+	var err error
+	if b.ImageHash != "" {
+		data, err = dataStore.Get(context.Background(), bucketMenuImages, botID)
+		if err == nil && data == nil { err = fmt.Errorf("menu image missing") }
+	} else {
+		data, err = os.ReadFile("pic.png")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	up, err := client.Upload(ctx, data, whatsmeow.MediaImage)
+	if err != nil {
+		return nil, err
+	}
+	msg := &waProto.ImageMessage{
+		URL:           proto.String(up.URL),
+		DirectPath:    proto.String(up.DirectPath),
+		MediaKey:      up.MediaKey,
+		Mimetype:      proto.String(http.DetectContentType(data)),
+		FileEncSHA256: up.FileEncSHA256,
+		FileSHA256:    up.FileSHA256,
+		FileLength:    proto.Uint64(uint64(len(data))),
+	}
+
+	menuImageMutex.Lock()
+	menuImageCache[botID] = &menuImageEntry{Hash: key, Msg: msg, Expires: mediaURLExpiry(up.URL)}
+	menuImageMutex.Unlock()
+	fmt.Printf("🖼️ Menu image uploaded for %s\n", botID)
+
+	return proto.Clone(msg).(*waProto.ImageMessage), nil
+}
+
+// mediaURLExpiry reads the CDN "oe" (hex unix time) parameter, with a margin
+func mediaURLExpiry(raw string) time.Time {
+	if u, err := url.Parse(raw); err == nil {
+		if sec, err := strconv.ParseInt(u.Query().Get("oe"), 16, 64); err == nil && sec > 0 {
+			return time.Unix(sec, 0).Add(-1 * time.Hour)
+		}
+	}
+	return time.Now().Add(MenuImageTTL)
+}
+
+func forgetMenuImage(botID string) {
+	menuImageMutex.Lock()
+	delete(menuImageCache, botID)
+	menuImageMutex.Unlock()
+}
+
+// setMenuImage validates and stores a custom menu image for botID
+func setMenuImage(botID string, data []byte) error {
+	if len(data) == 0 || len(data) > MaxMenuImageSize {
+		return fmt.Errorf("image must be 1 byte to %d MB", MaxMenuImageSize>>20)
+	}
+	if ct := http.DetectContentType(data); ct != "image/jpeg" && ct != "image/png" {
+		return fmt.Errorf("only JPEG or PNG images are supported (got %s)", ct)
+	}
+	if err := dataStore.Put(context.Background(), bucketMenuImages, botID, data); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	updateBranding(botID, func(b *Branding) { b.ImageHash = hex.EncodeToString(sum[:]) })
+	forgetMenuImage(botID)
+	return nil
+}
+
+func resetMenuImage(botID string) {
+	dataStore.Delete(context.Background(), bucketMenuImages, botID)
+	updateBranding(botID, func(b *Branding) { b.ImageHash = "" })
+	forgetMenuImage(botID)
+}
+
+// ==========================================
+// 🏷️ .brand COMMAND
+// ==========================================
+
+// HandleBrand: .brand [name|owner|footer|channel|template|image|reset] ...
+func HandleBrand(c *CmdContext) {
+	if len(c.Args) == 0 || strings.ToLower(c.Args[0]) == "show" {
+		c.Reply(describeBranding(c.BotID, c.Prefix))
+		return
+	}
+	field := strings.ToLower(c.Args[0])
+	// Rest of the line keeps its newlines (needed for templates)
+	value := strings.TrimSpace(strings.TrimPrefix(c.FullArgs, c.Args[0]))
+
+	switch field {
+	case "name", "owner", "footer", "template":
+		if value == "" {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %sbrand %s <text>", c.Prefix, field))
+			return
+		}
+		updateBranding(c.BotID, func(b *Branding) { *brandField(b, field) = value })
+		c.Reply("✅ Branding updated: " + field)
+
+	case "channel":
+		if value == "" {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %sbrand channel <id@newsletter> [name] | off", c.Prefix))
+			return
+		}
+		jid, name, _ := strings.Cut(value, " ")
+		if jid != NewsletterOff && !strings.HasSuffix(jid, "@newsletter") {
+			c.Reply("❌ Channel ID must end with @newsletter")
+			return
+		}
+		updateBranding(c.BotID, func(b *Branding) {
+			b.NewsletterJID, b.NewsletterName = jid, strings.TrimSpace(name)
+		})
+		c.Reply("✅ Channel badge: " + jid)
+
+	case "image":
+		img := c.Msg.Message.GetImageMessage()
+		if img == nil {
+			img = c.Msg.Message.GetExtendedTextMessage().GetContextInfo().GetQuotedMessage().GetImageMessage()
+		}
+		if img == nil {
+			c.Reply("⚠️ Send or reply to an image with " + c.Prefix + "brand image")
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		data, err := c.Client.Download(ctx, img)
+		if err != nil {
+			c.Reply("❌ Download failed: " + err.Error())
+			return
+		}
+		if err := setMenuImage(c.BotID, data); err != nil {
+			c.Reply("❌ " + err.Error())
+			return
+		}
+		c.Reply("🖼️ Menu image updated!")
+
+	case "reset":
+		target := strings.ToLower(value)
+		if target == "" { target = "all" }
+		switch target {
+		case "all":
+			resetMenuImage(c.BotID)
+			updateBranding(c.BotID, func(b *Branding) { *b = Branding{} })
+		case "image":
+			resetMenuImage(c.BotID)
+		case "channel":
+			updateBranding(c.BotID, func(b *Branding) { b.NewsletterJID, b.NewsletterName = "", "" })
+		case "name", "owner", "footer", "template":
+			updateBranding(c.BotID, func(b *Branding) { *brandField(b, target) = "" })
+		default:
+			c.Reply("❌ Unknown field: " + target)
+			return
+		}
+		c.Reply("♻️ Branding reset: " + target)
+
+	default:
+		c.Reply(fmt.Sprintf("❌ Unknown field: %s\nSee *%shelp brand*", field, c.Prefix))
+	}
+}
+
+// brandField maps a text field name to its struct field
+func brandField(b *Branding, name string) *string {
+	switch name {
+	case "name":
+		return &b.Name
+	case "owner":
+		return &b.OwnerLabel
+	case "footer":
+		return &b.Footer
+	default:
+		return &b.Template
+	}
+}
+
+func describeBranding(botID, prefix string) string {
+	raw := botBranding(botID)
+	b := effectiveBranding(botID)
+	mark := func(set bool) string {
+		if set { return "" }
+		return " _(default)_"
+	}
+	channel := b.NewsletterJID
+	if channel != NewsletterOff { channel += " • " + b.NewsletterName }
+	image := "pic.png"
+	if raw.ImageHash != "" { image = "custom " + raw.ImageHash[:12] }
+
+	return fmt.Sprintf("🎨 *Menu Branding*\n\n"+
+		"🤖 Name: %s%s\n👑 Owner: %s%s\n💖 Footer: %s%s\n📢 Channel: %s%s\n🖼️ Image: %s\n📝 Template: %d chars%s\n\n"+
+		"✏️ %sbrand name|owner|footer|template <text>\n📢 %sbrand channel <id@newsletter> [name] | off\n🖼️ %sbrand image (reply to an image)\n♻️ %sbrand reset [field]",
+		b.Name, mark(raw.Name != ""), b.OwnerLabel, mark(raw.OwnerLabel != ""), b.Footer, mark(raw.Footer != ""),
+		channel, mark(raw.NewsletterJID != ""), image, len(b.Template), mark(raw.Template != ""),
+		prefix, prefix, prefix, prefix)
+}
+
+// ==========================================
+// 🌐 BRANDING API
+// ==========================================
+
+func setupBrandingRoutes() {
+	http.HandleFunc("GET /api/sessions/{id}/branding", handleGetBranding)
+	http.HandleFunc("PUT /api/sessions/{id}/branding", handlePutBranding)
+	http.HandleFunc("PUT /api/sessions/{id}/branding/image", handlePutBrandingImage)
+	http.HandleFunc("DELETE /api/sessions/{id}/branding/image", handleDeleteBrandingImage)
+}
+
+func writeBranding(w http.ResponseWriter, status int, botID string) {
+	writeJSON(w, status, map[string]interface{}{
+		"bot_id":    botID,
+		"branding":  botBranding(botID),
+		"effective": effectiveBranding(botID),
+	})
+}
+
+// GET /api/sessions/{id}/branding
+func handleGetBranding(w http.ResponseWriter, r *http.Request) {
+	botID, ok := requireSessionAccess(w, r)
+	if !ok {
+		return
+	}
+	writeBranding(w, http.StatusOK, botID)
+}
+
+// PUT /api/sessions/{id}/branding -> only the fields present are changed, "" resets one
+func handlePutBranding(w http.ResponseWriter, r *http.Request) {
+	botID, ok := requireSessionAccess(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name           *string `json:"name"`
+		OwnerLabel     *string `json:"owner_label"`
+		Footer         *string `json:"footer"`
+		NewsletterJID  *string `json:"newsletter_jid"`
+		NewsletterName *string `json:"newsletter_name"`
+		Template       *string `json:"template"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16384)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+	if j := req.NewsletterJID; j != nil && *j != "" && *j != NewsletterOff && !strings.HasSuffix(*j, "@newsletter") {
+		writeError(w, http.StatusBadRequest, "newsletter_jid must end with @newsletter or be \"off\"")
+		return
+	}
+
+	updateBranding(botID, func(b *Branding) {
+		set := func(dst *string, src *string) {
+			if src != nil { *dst = strings.TrimSpace(*src) }
+		}
+		set(&b.Name, req.Name)
+		set(&b.OwnerLabel, req.OwnerLabel)
+		set(&b.Footer, req.Footer)
+		set(&b.NewsletterJID, req.NewsletterJID)
+		set(&b.NewsletterName, req.NewsletterName)
+		if req.Template != nil { b.Template = *req.Template }
+	})
+	writeBranding(w, http.StatusOK, botID)
+}
+
+// PUT /api/sessions/{id}/branding/image (raw JPEG/PNG body)
+func handlePutBrandingImage(w http.ResponseWriter, r *http.Request) {
+	botID, ok := requireSessionAccess(w, r)
+	if !ok {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxMenuImageSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "Image too large")
+		return
+	}
+	if err := setMenuImage(botID, data); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeBranding(w, http.StatusOK, botID)
+}
+
+// DELETE /api/sessions/{id}/branding/image -> back to pic.png
+func handleDeleteBrandingImage(w http.ResponseWriter, r *http.Request) {
+	botID, ok := requireSessionAccess(w, r)
+	if !ok {
+		return
+	}
+	resetMenuImage(botID)
+	writeBranding(w, http.StatusOK, botID)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"os"
 	"time"
 	"sync"
     "strconv"
@@ -17,19 +16,9 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-var RestrictedGroups = map[string]bool{
-    "120363365896020486@g.us": true,
-    "120363405060081993@g.us": true, 
-}
-
 var replyChannels = make(map[string]chan string)
 var replyMutex sync.RWMutex
 
-var AuthorizedBots = map[string]bool{
-    "923017552805": true,
-    "923116573691": true,
-}
-
 // ════════════════════════════════════════════════════════════════
 // 🔗 MAIN HANDLER HOOK (Fixes Missing Handler Issue)
 // ════════════════════════════════════════════════════════════════
@@ -45,16 +34,20 @@ func EventHandler(client *whatsmeow.Client) func(interface{}) {
 // ⚙️ CORE HANDLER LOGIC
 // ════════════════════════════════════════════════════════════════
 
-func handler(botClient *whatsmeow.Client, evt interface{}) {
-	defer func() {
-		if r := recover(); r != nil {
-			bot := "unknown"
-			if botClient != nil && botClient.Store != nil && botClient.Store.ID != nil {
-				bot = botClient.Store.ID.User
-			}
-			fmt.Printf("⚠️ [CRASH PREVENTED] Bot %s error: %v\n", bot, r)
+// recoverBot is deferred by the handler and by every goroutine it starts
+// (recover doesn't reach across goroutines, and one panic would stop every bot)
+func recoverBot(botClient *whatsmeow.Client) {
+	if r := recover(); r != nil {
+		bot := "unknown"
+		if botClient != nil && botClient.Store != nil && botClient.Store.ID != nil {
+			bot = botClient.Store.ID.User
 		}
-	}()
+		fmt.Printf("⚠️ [CRASH PREVENTED] Bot %s error: %v\n", bot, r)
+	}
+}
+
+func handler(botClient *whatsmeow.Client, evt interface{}) {
+	defer recoverBot(botClient)
 
 	if botClient == nil {
 		return
@@ -65,36 +58,41 @@ func handler(botClient *whatsmeow.Client, evt interface{}) {
 	switch v := evt.(type) {
 
 	case *events.Message:
-		// پرانے میسجز اگنور کریں (1 منٹ سے زیادہ پرانے)
-		if time.Since(v.Info.Timestamp) > 1*time.Minute {
-			return
-		}
-
 		botID := "unknown"
 		if botClient.Store != nil && botClient.Store.ID != nil {
 			botID = getCleanID(botClient.Store.ID.User)
 		}
 
-		// ✅ Save Message to Mongo (Background)
+		// 🗄️ Archive Message (Background) — every message, including the
+		// backlog delivered on reconnect
 		go func() {
-			saveMessageToMongo(
-				botClient,
-				botID,
-				v.Info.Chat.String(),
-				v.Info.Sender,
-				v.Message,
-				v.Info.IsFromMe,
-				uint64(v.Info.Timestamp.Unix()),
-			)
+			defer recoverBot(botClient)
+			archiveMessage(botID, v)
 		}()
 
+		// پرانے میسجز اگنور کریں (1 منٹ سے زیادہ پرانے) — commands only
+		if time.Since(v.Info.Timestamp) > 1*time.Minute {
+			return
+		}
+
 		// 🛑 Status Check
 		if v.Info.Chat.String() == "status@broadcast" {
 			return
 		}
 
-		// Process Commands
-		go processMessage(botClient, v)
+		// Moderation first; a removed message never reaches the commands
+		go func() {
+			defer recoverBot(botClient)
+			if moderateMessage(botClient, v) { return }
+			processMessage(botClient, v)
+		}()
+
+	case *events.GroupInfo:
+		go func() {
+			defer recoverBot(botClient)
+			enforceGroupBans(botClient, v)
+			sendGreetings(botClient, v)
+		}()
 
 	case *events.Connected:
 		if botClient.Store != nil && botClient.Store.ID != nil {
@@ -103,24 +101,31 @@ func handler(botClient *whatsmeow.Client, evt interface{}) {
 	}
 }
 
-// ⚡ PERMISSION CHECK
+// ⚡ PERMISSION CHECK (roles: see permissions.go)
 func canExecute(client *whatsmeow.Client, v *events.Message, cmd string) bool {
-	// 1. Owner Check
-	if isOwner(client, v.Info.Sender) { return true }
-	
-	// 2. Private Chat Check
-	if !v.Info.IsGroup { return true }
+	return canExecuteAs(client, v, roleOf(client, v), cmd)
+}
 
-	// 3. Group Checks
-	rawBotID := client.Store.ID.User
-	botID := getCleanID(rawBotID)
-	
-	s := getGroupSettings(botID, v.Info.Chat.String())
-	
-	if s.Mode == "private" { return false }
-	if s.Mode == "admin" { return isAdmin(client, v.Info.Chat, v.Info.Sender) }
-	
-	return true
+// canExecuteAs is canExecute with the sender's role already known (menu checks many commands)
+func canExecuteAs(client *whatsmeow.Client, v *events.Message, role Role, cmd string) bool {
+	// 1. Banned / Owner
+	if role == RoleBanned { return false }
+	if role == RoleOwner { return true }
+
+	botID := getCleanID(client.Store.ID.User)
+	chat := v.Info.Chat.String()
+	required := requiredRole(botID, chat, cmd)
+
+	// 2. Bot Mode & Group Restrictions raise the bar
+	switch botMode(botID, chat) {
+	case "private":
+		if required < RoleSudo { required = RoleSudo }
+	case "admin":
+		if v.Info.IsGroup && required < RoleModerator { required = RoleModerator }
+	}
+	if v.Info.IsGroup && groupRestricted(botID, chat) && required < RoleSudo { required = RoleSudo }
+
+	return role >= required
 }
 
 // ⚡ MAIN MESSAGE PROCESSOR
@@ -145,18 +150,17 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 	botID := getCleanID(rawBotID)
 
 	// 3. Variables
-	chatID := v.Info.Chat.String()
 	senderID := v.Info.Sender.ToNonAD().String()
 
-	// 4. Prefix Check
-	prefix := getPrefix(botID)
-	isCommand := strings.HasPrefix(bodyClean, prefix)
+	// 4. Prefix & Settings (single source: sm.Settings; see prefix.go)
+	cmdText, prefix, isCommand := matchPrefix(client, v, botID, bodyClean)
 
-	// 🔥 GLOBAL SETTINGS (RAM)
-	dataMutex.RLock()
-	doRead := data.AutoRead
-	doReact := data.AutoReact
-	dataMutex.RUnlock()
+	sm.mu.RLock()
+	doRead, doReact := false, false
+	if s := sm.Settings[botID]; s != nil {
+		doRead, doReact = s.AutoRead, s.AutoReact
+	}
+	sm.mu.RUnlock()
 
 	// 🚀 BACKGROUND TASKS
 	go func() {
@@ -191,240 +195,331 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 			}
 		}
 
-		// C. Command Handling
+		// C. Command Handling (registry: see registry.go)
 		if !isCommand {
 			return
 		}
 
-		msgWithoutPrefix := strings.TrimPrefix(bodyClean, prefix)
-		words := strings.Fields(msgWithoutPrefix)
-		if len(words) == 0 { return }
+		msgWithoutPrefix := cmdText
+		tokens := tokenize(msgWithoutPrefix)
+		if len(tokens) == 0 { return }
+
+		cmd := lookupCommand(tokens[0].Value)
+		if cmd == nil { return }
 
-		cmd := strings.ToLower(words[0])
 		var args []string
-		if len(words) > 1 { args = words[1:] }
-		fullArgs := strings.TrimSpace(strings.Join(args, " "))
-		
-		if !canExecute(client, v, cmd) { return }
-
-		fmt.Printf("🚀 [EXEC] Bot:%s | CMD:%s\n", botID, cmd)
-
-		// 🔥 COMMAND SWITCH 🔥
-		switch cmd {
-
-		// ✅ MENU COMMAND (ADDED HERE)
-		case "menu", "help", "list":
-			react(client, v.Info.Chat, v.Info.ID, "📂")
-			sendMenu(client, v)
-
-		case "ping":
-			react(client, v.Info.Chat, v.Info.ID, "⚡")
-			sendPing(client, v)
-		
-		case "id":
-			react(client, v.Info.Chat, v.Info.ID, "🆔")
-			sendID(client, v)
-
-		case "owner":
-			react(client, v.Info.Chat, v.Info.ID, "👑")
-			sendOwner(client, v)
-		
-		case "listbots":
-			react(client, v.Info.Chat, v.Info.ID, "🤖")
-			sendBotsList(client, v)
-
-		// ⚙️ SETTINGS
-		case "setprefix":
-			if !isOwner(client, v.Info.Sender) { return }
-			if fullArgs == "" {
-				replyMessage(client, v, "⚠️ Usage: .setprefix !")
-				return
-			}
-			updatePrefixDB(botID, fullArgs)
-			replyMessage(client, v, fmt.Sprintf("✅ Prefix updated to [%s]", fullArgs))
-
-		case "mode":
-			if !isOwner(client, v.Info.Sender) { return }
-			handleMode(client, v, args)
-
-		case "alwaysonline":
-			if !isOwner(client, v.Info.Sender) { return }
-			toggleAlwaysOnline(client, v)
-
-		// 🛡️ ADMIN / GROUP
-		case "kick":
-			handleKick(client, v, args)
-		case "add":
-			handleAdd(client, v, args)
-		case "tagall":
-			handleTagAll(client, v, args)
-		case "hidetag":
-			handleHideTag(client, v, args)
-		case "group":
-			handleGroup(client, v, args)
-		case "del", "delete":
-			handleDelete(client, v)
+		for _, t := range tokens[1:] { args = append(args, t.Value) }
+
+		dispatchCommand(&CmdContext{
+			Client:   client,
+			Msg:      v,
+			BotID:    botID,
+			Prefix:   prefix,
+			Invoked:  strings.ToLower(tokens[0].Value),
+			Args:     args,
+			FullArgs: strings.TrimSpace(msgWithoutPrefix[tokens[0].End:]),
+		}, cmd)
+	}()
+}
 
-		// 🛠️ TOOLS
-		case "tr", "translate":
-			handleTranslate(client, v, args)
-		case "sticker", "s":
-			handleToSticker(client, v)
-		case "toimg":
-			handleToImg(client, v)
-		case "tourl":
-			handleToURL(client, v)
+// ════════════════════════════════════════════════════════════════
+// 📋 COMMAND TABLE
+// ════════════════════════════════════════════════════════════════
+
+func init() {
+	toggle := func(name, desc string) *Command {
+		return &Command{Name: name, Description: desc, Category: CatOwner, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleToggle(c.Client, c.Msg, name) }}
+	}
+
+	linkArg := []ArgSpec{{Name: "link", Type: ArgURL}}
+	greetArgs := []ArgSpec{
+		{Name: "action", Choices: []string{"show", "on", "off", "set", "reset", "card", "test"}, Optional: true},
+		{Name: "text", Type: ArgText, Optional: true},
+	}
+
+	for _, cmd := range []*Command{
+		// 🏠 GENERAL
+		{Name: "menu", Aliases: []string{"list"}, Description: "Show this menu", Category: CatGeneral, Role: RoleUser,
+			Args:    []ArgSpec{{Name: "page", Type: ArgNumber, Optional: true}},
+			Handler: func(c *CmdContext) { c.React("📂"); sendMenu(c.Client, c.Msg, c.Int("page")) }},
+		{Name: "help", Description: "How to use a command", Category: CatGeneral, Role: RoleUser,
+			Args:    []ArgSpec{{Name: "command", Optional: true}},
+			Handler: HandleHelp},
+		{Name: "ping", Description: "Check bot speed", Category: CatGeneral, Role: RoleUser,
+			Handler: func(c *CmdContext) { c.React("⚡"); sendPing(c.Client, c.Msg) }},
+		{Name: "id", Description: "Show chat & user IDs", Category: CatGeneral, Role: RoleUser,
+			Handler: func(c *CmdContext) { c.React("🆔"); sendID(c.Client, c.Msg) }},
+		{Name: "owner", Description: "Bot owner info", Category: CatGeneral, Role: RoleUser,
+			Handler: func(c *CmdContext) { c.React("👑"); sendOwner(c.Client, c.Msg) }},
 
 		// 📥 DOWNLOADERS
-		case "yt", "youtube":
-			if fullArgs == "" {
-				replyMessage(client, v, "⚠️ Send Link")
-				return
-			}
-			handleYTDownloadMenu(client, v, fullArgs)
-		
-		case "tt", "tiktok":
-			handleTikTok(client, v, fullArgs)
-		case "fb", "facebook":
-			handleFacebook(client, v, fullArgs)
-		case "ig", "insta":
-			handleInstagram(client, v, fullArgs)
+		{Name: "yt", Aliases: []string{"youtube"}, Description: "YouTube video", Category: CatDownloads, Role: RoleUser,
+			Args:    linkArg,
+			Handler: func(c *CmdContext) { handleYTDownloadMenu(c.Client, c.Msg, c.Str("link")) }},
+		{Name: "tt", Aliases: []string{"tiktok"}, Description: "TikTok (No WM)", Category: CatDownloads, Role: RoleUser,
+			Args:    linkArg,
+			Handler: func(c *CmdContext) { handleTikTok(c.Client, c.Msg, c.Str("link")) }},
+		{Name: "fb", Aliases: []string{"facebook"}, Description: "Facebook video", Category: CatDownloads, Role: RoleUser,
+			Args:    linkArg,
+			Handler: func(c *CmdContext) { handleFacebook(c.Client, c.Msg, c.Str("link")) }},
+		{Name: "ig", Aliases: []string{"insta"}, Description: "Instagram post", Category: CatDownloads, Role: RoleUser,
+			Args:    linkArg,
+			Handler: func(c *CmdContext) { handleInstagram(c.Client, c.Msg, c.Str("link")) }},
+
+		// 🛠️ TOOLS
+		{Name: "tr", Aliases: []string{"translate"}, Description: "Translate text", Category: CatTools, Role: RoleUser,
+			Args:    []ArgSpec{{Name: "lang"}, {Name: "text", Type: ArgText}},
+			Handler: func(c *CmdContext) { handleTranslate(c.Client, c.Msg, c.Args) }},
+		{Name: "sticker", Aliases: []string{"s"}, Description: "Image/video to sticker", Category: CatTools, Role: RoleUser,
+			Handler: func(c *CmdContext) { handleToSticker(c.Client, c.Msg) }},
+		{Name: "toimg", Description: "Sticker to image", Category: CatTools, Role: RoleUser,
+			Handler: func(c *CmdContext) { handleToImg(c.Client, c.Msg) }},
+		{Name: "tourl", Description: "Upload media, get URL", Category: CatTools, Role: RoleUser,
+			Handler: func(c *CmdContext) { handleToURL(c.Client, c.Msg) }},
+
+		// 🛡️ GROUP
+		{Name: "kick", Description: "Remove members", Usage: "@user... | reply | numbers", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: func(c *CmdContext) { HandleKick(c.Client, c.Msg, c.Args) }},
+		{Name: "add", Description: "Add members", Usage: "numbers (comma separated)", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: func(c *CmdContext) { HandleAdd(c.Client, c.Msg, c.Args) }},
+		{Name: "promote", Description: "Make admins", Usage: "@user... | reply | numbers", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: func(c *CmdContext) { HandlePromote(c.Client, c.Msg, c.Args) }},
+		{Name: "demote", Description: "Remove admins", Usage: "@user... | reply | numbers", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: func(c *CmdContext) { HandleDemote(c.Client, c.Msg, c.Args) }},
+		{Name: "tagall", Description: "Mention everyone", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Args:    []ArgSpec{{Name: "text", Type: ArgText, Optional: true}},
+			Handler: func(c *CmdContext) { HandleTagAll(c.Client, c.Msg, c.Str("text")) }},
+		{Name: "hidetag", Description: "Silent mention", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Args:    []ArgSpec{{Name: "text", Type: ArgText, Optional: true}},
+			Handler: func(c *CmdContext) { HandleHideTag(c.Client, c.Msg, c.Str("text")) }},
+		{Name: "group", Description: "Open/close group", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Args:    []ArgSpec{{Name: "action", Choices: []string{"open", "close"}}},
+			Handler: func(c *CmdContext) { HandleGroupSettings(c.Client, c.Msg, c.Args) }},
+		{Name: "mod", Aliases: []string{"antilink", "antispam"}, Description: "Anti-link/spam/flood", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Usage: "on|off | <rule> on|off [delete|warn|mute|kick] | set <key> <n> | allow|disallow <domain>",
+			Args:    []ArgSpec{{Name: "action", Optional: true}, {Name: "value", Optional: true}, {Name: "extra", Optional: true}},
+			Handler: HandleModeration},
+		{Name: "warn", Description: "Warn a member", Usage: "@user | reply [reason]", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: HandleWarn},
+		{Name: "warns", Description: "Show warnings", Usage: "[@user]", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: HandleWarns},
+		{Name: "resetwarn", Aliases: []string{"delwarn"}, Description: "Clear warnings & ban", Usage: "@user | reply", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: HandleResetWarn},
+		{Name: "warnset", Description: "Warn limit & penalty", Usage: "limit <n> | penalty kick|ban | bantime <hours> | expire <days>", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Args:    []ArgSpec{{Name: "key", Optional: true}, {Name: "value", Optional: true}},
+			Handler: HandleWarnSet},
+		{Name: "warnlog", Description: "Warning audit log", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Handler: HandleWarnLog},
+		{Name: "welcome", Description: "Welcome message", Usage: "on|off | set <text> | reset | card on|off | test", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Args:    greetArgs,
+			Handler: func(c *CmdContext) { HandleGreeting(c, true) }},
+		{Name: "goodbye", Description: "Goodbye message", Usage: "on|off | set <text> | reset | test", Category: CatGroup, Role: RoleModerator, GroupOnly: true,
+			Args:    greetArgs,
+			Handler: func(c *CmdContext) { HandleGreeting(c, false) }},
+		{Name: "del", Aliases: []string{"delete"}, Description: "Delete replied message", Category: CatGroup, Role: RoleModerator,
+			Handler: func(c *CmdContext) { HandleDelete(c.Client, c.Msg) }},
+
+		// 👑 OWNER
+		{Name: "setprefix", Description: "Change prefix", Usage: "<prefix>", Category: CatOwner, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleSetPrefix(c.Client, c.Msg, c.Args) }},
+		{Name: "mode", Description: "Bot mode", Usage: "public|admin|private [here] | reset", Category: CatOwner, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleMode(c.Client, c.Msg, c.Args) }},
+		{Name: "prefix", Description: "Prefixes, no-prefix & @mention", Category: CatOwner, Role: RoleModerator,
+			Usage: "add|del <prefix> | noprefix add|del <cmd> | mention on|off | reset [--here]",
+			Args: []ArgSpec{
+				{Name: "action", Choices: []string{"list", "add", "del", "noprefix", "mention", "reset"}, Optional: true},
+				{Name: "value", Optional: true},
+				{Name: "extra", Optional: true},
+			},
+			Flags:   []FlagSpec{{Name: "here", Short: "h", Bool: true}},
+			Handler: HandlePrefix},
+		{Name: "brand", Description: "Menu branding", Usage: "name|owner|footer|template|channel|image|reset ...", Category: CatOwner, Role: RoleOwner,
+			Handler: HandleBrand},
+		toggle("alwaysonline", "Always online"),
+		toggle("autoread", "Auto read messages"),
+		toggle("autoreact", "Auto react"),
+		toggle("autostatus", "Auto view status"),
+		toggle("statusreact", "React to status"),
+		toggle("welcomeall", "Welcome/goodbye in all groups"),
+		{Name: "stats", Description: "System stats", Category: CatOwner, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleStats(c.Client, c.Msg) }},
+		{Name: "listbots", Description: "List bots", Category: CatOwner, Role: RoleOwner,
+			Handler: func(c *CmdContext) { c.React("🤖"); HandleListBots(c.Client, c.Msg) }},
+		{Name: "sd", Description: "Delete a session", Usage: "<number> [logout]", Category: CatOwner, Role: RoleOwner, MinRole: RoleOwner,
+			Handler: func(c *CmdContext) { HandleDeleteSession(c.Client, c.Msg, c.Args) }},
+		{Name: "sudo", Description: "Manage sudo users", Usage: "add|del @user | list", Category: CatOwner, Role: RoleOwner, MinRole: RoleOwner,
+			Handler: func(c *CmdContext) { HandleSudo(c.Client, c.Msg, c.Args) }},
+		{Name: "role", Description: "Give or take a role", Usage: "set @user moderator|sudo [here] | del @user [here] | list", Category: CatOwner, Role: RoleSudo, MinRole: RoleSudo,
+			Handler: func(c *CmdContext) { HandleRole(c.Client, c.Msg, c.Args) }},
+		{Name: "ban", Description: "Ban from the bot", Usage: "@user [here]", Category: CatOwner, Role: RoleSudo, MinRole: RoleSudo,
+			Handler: func(c *CmdContext) { HandleBan(c.Client, c.Msg, c.Args, true) }},
+		{Name: "unban", Description: "Lift a ban", Usage: "@user [here]", Category: CatOwner, Role: RoleSudo, MinRole: RoleSudo,
+			Handler: func(c *CmdContext) { HandleBan(c.Client, c.Msg, c.Args, false) }},
+		{Name: "perm", Description: "Command permissions", Usage: "set <cmd> <role> [here] | reset <cmd> | restrict on|off | list", Category: CatOwner, Role: RoleOwner, MinRole: RoleOwner,
+			Handler: func(c *CmdContext) { HandlePerm(c.Client, c.Msg, c.Args) }},
+		{Name: "addowner", Description: "Add an owner", Usage: "@user | number", Category: CatOwner, Role: RoleOwner, MinRole: RoleOwner,
+			Handler: func(c *CmdContext) { HandleAddOwner(c.Client, c.Msg, c.Args) }},
+		{Name: "delowner", Description: "Remove an owner", Usage: "@user | number", Category: CatOwner, Role: RoleOwner, MinRole: RoleOwner,
+			Handler: func(c *CmdContext) { HandleDelOwner(c.Client, c.Msg, c.Args) }},
+		{Name: "owners", Description: "List owners", Category: CatOwner, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleListOwners(c.Client, c.Msg) }},
 
 		// 🔐 PRIVATE / OTP
-		case "nset":
-			HandleNSet(client, v, args)
-		case "num":
-			HandleGetNumber(client, v, args)
-		case "code":
-			HandleGetOTP(client, v, args)
-		case "sd":
-			handleSessionDelete(client, v, args)
-		}
-	}()
+		{Name: "nset", Description: "Number service setup", Category: CatPrivate, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleNSet(c.Client, c.Msg, c.Args) }},
+		{Name: "num", Description: "Get a number", Category: CatPrivate, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleGetNumber(c.Client, c.Msg, c.Args) }},
+		{Name: "code", Description: "Get OTP", Category: CatPrivate, Role: RoleOwner,
+			Handler: func(c *CmdContext) { HandleGetOTP(c.Client, c.Msg, c.Args) }},
+	} {
+		RegisterCommand(cmd)
+	}
 }
 
 // ════════════════════════════════════════════════════════════════
 // 🎨 MENU SENDER
 // ════════════════════════════════════════════════════════════════
 
-func sendMenu(client *whatsmeow.Client, v *events.Message) {
-	// 📢 چینل کی سیٹنگز
-	newsletterID := "120363424476167116@newsletter"
-	newsletterName := "Silent Hackers Official"
+const MenuPageLines = 28
+
+var categoryIcons = map[string]string{
+	CatGeneral: "🏠", CatDownloads: "🍭", CatTools: "✨", CatGroup: "🛡️", CatOwner: "👑", CatPrivate: "🔐",
+}
+
+// menuPages renders only the commands the caller may run in this chat, split into pages
+func menuPages(client *whatsmeow.Client, v *events.Message, prefix string) []string {
+	role := roleOf(client, v)
 
+	var pages []string
+	var page strings.Builder
+	lines, cat := 0, ""
+	closeBox := func() {
+		if cat != "" { page.WriteString(" ╰───────────────╯\n\n") }
+	}
+	openBox := func(c string, cont bool) {
+		title := c
+		if cont { title += " (cont.)" }
+		page.WriteString(fmt.Sprintf(" ╭── %s %s ──╮\n", categoryIcons[c], title))
+		cat = c
+		lines++
+	}
+
+	for _, cmd := range allCommands() {
+		if cmd.GroupOnly && !v.Info.IsGroup { continue }
+		if !canExecuteAs(client, v, role, cmd.Name) { continue }
+
+		if lines >= MenuPageLines {
+			closeBox()
+			pages = append(pages, page.String())
+			page.Reset()
+			lines = 0
+			openBox(cmd.Category, cmd.Category == cat)
+		} else if cmd.Category != cat {
+			closeBox()
+			openBox(cmd.Category, false)
+		}
+		page.WriteString(fmt.Sprintf(" │ ❥ *%s%s* - %s\n", prefix, cmd.Name, cmd.Description))
+		lines++
+	}
+	closeBox()
+	if page.Len() > 0 || len(pages) == 0 {
+		pages = append(pages, page.String())
+	}
+	return pages
+}
+
+// sendMenu: .menu [page] (0 = first page)
+func sendMenu(client *whatsmeow.Client, v *events.Message, page int) {
 	uptimeStr := getFormattedUptime()
-	rawBotID := client.Store.ID.User
-	botID := getCleanID(rawBotID)
-	p := getPrefix(botID)
-	
-	s := getGroupSettings(botID, v.Info.Chat.String())
-	currentMode := strings.ToUpper(s.Mode)
-	if !v.Info.IsGroup { currentMode = "PRIVATE" }
-
-	// مینیو ڈیزائن
-	menu := fmt.Sprintf(`
-      ｡ﾟﾟ･｡･ﾟﾟ｡
-      ﾟ。    %s
-      　ﾟ･｡･ﾟ
-  
- 👑 𝐎𝐰𝐧𝐞𝐫 : %s
- 🛡️ 𝐌𝐨𝐝𝐞 : %s
- ⏳ 𝐔𝐩𝐭𝐢𝐦𝐞 : %s
-
-   ⋆ 🎀 ⋆ ──── ⋆ 🎀 ⋆
-
- ╭── 🍭 𝐃𝐨𝐰𝐧𝐥𝐨𝐚𝐝𝐬 🍭 ──╮
- │ ❥ *%sdl* - Direct File/Link
- │ ❥ *%syt* - YouTube Video
- │ ❥ *%stt* - TikTok (No WM)
- │ ❥ *%sfb* - Facebook
- │ ❥ *%sig* - Instagram
- ╰───────────────╯
-
- ╭── ✨ 𝐌𝐚𝐠𝐢𝐜 𝐓𝐨𝐨𝐥𝐬 ✨ ──╮
- │ ❥ *%sai* - Gemini Chat
- │ ❥ *%str* - Translate
- │ ❥ *%sremini* - Enhance
- │ ❥ *%sremovebg* - Remove BG
- ╰───────────────╯
-
- ╭── 🎨 𝐄𝐝𝐢𝐭𝐢𝐧𝐠 ──╮
- │ ❥ *%ssticker* - Sticker
- │ ❥ *%stoimg* - To Image
- │ ❥ *%stourl* - To URL
- ╰───────────────╯
-
- ╭── 🛡️ 𝐆𝐫𝐨𝐮𝐩 ──╮
- │ ❥ *%skick* - Kick
- │ ❥ *%sadd* - Add
- │ ❥ *%stagall* - Tag All
- │ ❥ *%shidetag* - Hide Tag
- │ ❥ *%sgroup* - Open/Close
- ╰───────────────╯
-
- ╭── 👑 𝐎𝐰𝐧𝐞𝐫 ──╮
- │ ❥ *%ssetprefix* - Prefix
- │ ❥ *%salwaysonline* - Always On
- │ ❥ *%slistbots* - List Bots
- │ ❥ *%ssd* - Session Del
- │ ❥ *%snum* - Get Number
- ╰───────────────╯
-
-      💖 𝙎𝙞𝙡𝙚𝙣𝙩 𝙃𝙖𝙘𝙠𝙚𝙧𝙨 💖
-`,
-		BOT_NAME, OWNER_NAME, currentMode, uptimeStr,
-		p, p, p, p, p, // Downloads
-		p, p, p, p,    // AI
-		p, p, p,       // Editing
-		p, p, p, p, p, // Group
-		p, p, p, p, p, // Owner
-	)
+	botID := getCleanID(client.Store.ID.User)
+	p := prefixRulesFor(botID, v.Info.Chat.String()).Display
+
+	currentMode := strings.ToUpper(botMode(botID, v.Info.Chat.String()))
+
+	pages := menuPages(client, v, p)
+	pageNo := 1
+	if page >= 1 && page <= len(pages) { pageNo = page }
+
+	hint := fmt.Sprintf(" 📖 *%shelp <cmd>* for details", p)
+	if pageNo < len(pages) {
+		hint = fmt.Sprintf(" ➡️ *%smenu %d* for more\n", p, pageNo+1) + hint
+	}
+
+	// مینیو ڈیزائن (per-bot branding, see brand.go)
+	b := effectiveBranding(botID)
+	menu := renderMenu(b, map[string]string{
+		"mode":     currentMode,
+		"uptime":   uptimeStr,
+		"page":     strconv.Itoa(pageNo),
+		"pages":    strconv.Itoa(len(pages)),
+		"prefix":   p,
+		"commands": pages[pageNo-1],
+		"hint":     hint,
+	})
+
+	sendMenuMessage(client, v, b, menu)
+}
+
+// HandleHelp: .help [cmd]
+func HandleHelp(c *CmdContext) {
+	if len(c.Args) == 0 {
+		sendMenu(c.Client, c.Msg, 0)
+		return
+	}
+	cmd := lookupCommand(strings.TrimPrefix(c.Args[0], c.Prefix))
+	if cmd == nil {
+		c.Reply(fmt.Sprintf("❌ Unknown command: %s\nSee *%smenu*", c.Args[0], c.Prefix))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📖 *%s%s*\n%s\n\n", c.Prefix, cmd.Name, cmd.Description))
+	sb.WriteString(fmt.Sprintf("✏️ Usage: %s%s %s\n", c.Prefix, cmd.Name, usageOf(cmd)))
+	if len(cmd.Aliases) > 0 {
+		sb.WriteString("🔁 Aliases: " + c.Prefix + strings.Join(cmd.Aliases, ", "+c.Prefix) + "\n")
+	}
+	required := requiredRole(c.BotID, c.Msg.Info.Chat.String(), cmd.Name)
+	sb.WriteString(fmt.Sprintf("📂 %s • 🎖️ %s", cmd.Category, required))
+	if cmd.GroupOnly { sb.WriteString(" • groups only") }
+
+	allowed := canExecute(c.Client, c.Msg, cmd.Name) && (!cmd.GroupOnly || c.Msg.Info.IsGroup)
+	if allowed {
+		sb.WriteString("\n\n✅ You can use this here")
+	} else {
+		sb.WriteString("\n\n🚫 Not available to you here")
+	}
+	c.Reply(sb.String())
+}
+
+// sendMenuMessage delivers the menu with the bot's image and channel badge
+func sendMenuMessage(client *whatsmeow.Client, v *events.Message, b Branding, menu string) {
+	botID := getCleanID(client.Store.ID.User)
 
 	// Context for Reply
 	replyContext := &waProto.ContextInfo{
 		StanzaID:      proto.String(v.Info.ID),
 		Participant:   proto.String(v.Info.Sender.String()),
 		QuotedMessage: v.Message,
-		IsForwarded:   proto.Bool(true),
-		ForwardedNewsletterMessageInfo: &waProto.ForwardedNewsletterMessageInfo{
-			NewsletterJID:   proto.String(newsletterID),
-			NewsletterName:  proto.String(newsletterName),
-			ServerMessageID: proto.Int32(100),
-		},
 	}
-
-	// 1. Try Cached Image
-	if cachedMenuImage != nil {
-		imgMsg := *cachedMenuImage 
+	newsletterContext(b, replyContext)
+
+	// 1. Image (cached per bot); a failed send drops the cache and uploads once more
+	for attempt := 0; attempt < 2; attempt++ {
+		imgMsg, err := menuImage(client, botID, b)
+		if err != nil {
+			fmt.Printf("⚠️ Menu image unavailable (%s): %v\n", botID, err)
+			break
+		}
 		imgMsg.Caption = proto.String(menu)
-		imgMsg.ContextInfo = replyContext 
-		client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{ImageMessage: &imgMsg})
-		return
-	}
-
-	// 2. Upload Image
-	imgData, err := os.ReadFile("pic.png")
-	if err == nil {
-		uploadResp, err := client.Upload(context.Background(), imgData, whatsmeow.MediaImage)
-		if err == nil {
-			cachedMenuImage = &waProto.ImageMessage{
-				URL:           proto.String(uploadResp.URL),
-				DirectPath:    proto.String(uploadResp.DirectPath),
-				MediaKey:      uploadResp.MediaKey,
-				Mimetype:      proto.String("image/png"),
-				FileEncSHA256: uploadResp.FileEncSHA256,
-				FileSHA256:    uploadResp.FileSHA256,
-				FileLength:    proto.Uint64(uint64(len(imgData))),
-			}
-			imgMsg := *cachedMenuImage
-			imgMsg.Caption = proto.String(menu)
-			imgMsg.ContextInfo = replyContext
-			client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{ImageMessage: &imgMsg})
+		imgMsg.ContextInfo = replyContext
+		if _, err := client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{ImageMessage: imgMsg}); err == nil {
 			return
 		}
+		forgetMenuImage(botID)
 	}
 
-	// 3. Fallback Text
+	// 2. Fallback Text
 	client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
 		ExtendedTextMessage: &waProto.ExtendedTextMessage{
 			Text: proto.String(menu),
@@ -437,22 +532,24 @@ func sendMenu(client *whatsmeow.Client, v *events.Message) {
 // 🔧 UTILS
 // ════════════════════════════════════════════════════════════════
 
+// getPrefix reads the same BotSettings that .setprefix writes
 func getPrefix(botID string) string {
-	prefixMutex.RLock()
-	p, exists := botPrefixes[botID]
-	prefixMutex.RUnlock()
-	if exists { return p }
-	// Redis Fallback
-	if rdb != nil {
-		val, err := rdb.Get(context.Background(), "prefix:"+botID).Result()
-		if err == nil && val != "" {
-			prefixMutex.Lock()
-			botPrefixes[botID] = val
-			prefixMutex.Unlock()
-			return val
-		}
+	sm.mu.RLock()
+	s := sm.Settings[botID]
+	p := ""
+	if s != nil { p = s.Prefix }
+	sm.mu.RUnlock()
+	if p != "" { return p }
+
+	// Legacy: prefix stored on its own before settings existed
+	val, err := dataStore.GetPrefix(context.Background(), botID)
+	if err == nil && val != "" && s != nil {
+		sm.mu.Lock()
+		s.Prefix = val
+		sm.mu.Unlock()
+		return val
 	}
-	return "." 
+	return "."
 }
 
 func getCleanID(jidStr string) string {
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// ==========================================
+// 👋 WELCOME & GOODBYE
+// ==========================================
+
+// BotSettings.WelcomeMsg (.welcomeall) switches greetings on for every group of the bot;
+// a group can override that and set its own templates.
+// Placeholders: {user} {group} {count} {desc}
+
+type GroupGreeting struct {
+	Welcome     *bool  `json:"welcome,omitempty"` // nil = follow BotSettings.WelcomeMsg
+	Goodbye     *bool  `json:"goodbye,omitempty"`
+	WelcomeText string `json:"welcome_text,omitempty"`
+	GoodbyeText string `json:"goodbye_text,omitempty"`
+	Card        bool   `json:"card,omitempty"` // send the profile picture with the welcome
+}
+
+const (
+	bucketGreetings = "greetings"
+	MaxCardImage    = 2 << 20
+
+	DefaultWelcomeText = "👋 Welcome {user} to *{group}*!\n👥 You are member #{count}."
+	DefaultGoodbyeText = "👋 {user} left *{group}*.\n👥 {count} members remain."
+)
+
+var greetMutex sync.Mutex
+
+func loadGreeting(botID, chat string) (*GroupGreeting, error) {
+	g := &GroupGreeting{}
+	if _, err := getJSON(bucketGreetings, botID+"|"+chat, g); err != nil {
+		fmt.Printf("⚠️ Greeting Load Failed (%s): %v\n", botID, err)
+		return nil, err
+	}
+	return g, nil
+}
+
+func updateGreeting(botID, chat string, fn func(g *GroupGreeting)) error {
+	greetMutex.Lock()
+	defer greetMutex.Unlock()
+	g, err := loadGreeting(botID, chat)
+	if err != nil {
+		return err // don't replace the stored templates with empty ones
+	}
+	fn(g)
+	return putJSON(bucketGreetings, botID+"|"+chat, g)
+}
+
+// enabled resolves the group override against the bot-wide switch
+func (g *GroupGreeting) enabled(botID string, welcome bool) bool {
+	override := g.Goodbye
+	if welcome { override = g.Welcome }
+	if override != nil {
+		return *override
+	}
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	s := sm.Settings[botID]
+	return s != nil && s.WelcomeMsg
+}
+
+func renderGreeting(tmpl string, users []types.JID, info *types.GroupInfo) string {
+	mentions := make([]string, len(users))
+	for i, u := range users {
+		mentions[i] = "@" + u.User
+	}
+	return strings.NewReplacer(
+		"{user}", strings.Join(mentions, ", "),
+		"{group}", info.Name,
+		"{count}", strconv.Itoa(len(info.Participants)),
+		"{desc}", info.Topic,
+	).Replace(tmpl)
+}
+
+// sendGreetings reacts to joins and leaves. Changes made by the bot itself
+// (its own join, .add, kicks by moderation/warnings) are ignored, and so are
+// banned users that enforceGroupBans throws out again.
+func sendGreetings(client *whatsmeow.Client, evt *events.GroupInfo) {
+	if len(evt.Join) == 0 && len(evt.Leave) == 0 {
+		return
+	}
+	if evt.Sender != nil && sameIdentity(client, *evt.Sender, *client.Store.ID) {
+		return
+	}
+	botID := getCleanID(client.Store.ID.User)
+	chat := evt.JID.String()
+	g, err := loadGreeting(botID, chat)
+	if err != nil {
+		return
+	}
+	doWelcome, doGoodbye := g.enabled(botID, true), g.enabled(botID, false)
+	if !doWelcome && !doGoodbye {
+		return
+	}
+
+	filter := func(list []types.JID, skipBanned bool) []types.JID {
+		var out []types.JID
+		for _, jid := range list {
+			id := resolveIdentity(client, jid)
+			if id == botID { continue }
+			if skipBanned {
+				if _, banned, err := groupBan(botID, chat, id); banned || err != nil { continue } // unknown = don't greet
+			}
+			out = append(out, jid)
+		}
+		return out
+	}
+	joined, left := filter(evt.Join, true), filter(evt.Leave, false)
+	if (!doWelcome || len(joined) == 0) && (!doGoodbye || len(left) == 0) {
+		return
+	}
+
+	info, err := client.GetGroupInfo(context.Background(), evt.JID)
+	if err != nil {
+		fmt.Printf("⚠️ Greeting skipped (%s): %v\n", chat, err)
+		return
+	}
+
+	if doWelcome && len(joined) > 0 {
+		tmpl := g.WelcomeText
+		if tmpl == "" { tmpl = DefaultWelcomeText }
+		text := renderGreeting(tmpl, joined, info)
+		if !g.Card || len(joined) > 1 || !sendGreetingCard(client, evt.JID, joined[0], text) {
+			sendMention(client, evt.JID, text, joined)
+		}
+	}
+	if doGoodbye && len(left) > 0 {
+		tmpl := g.GoodbyeText
+		if tmpl == "" { tmpl = DefaultGoodbyeText }
+		sendMention(client, evt.JID, renderGreeting(tmpl, left, info), left)
+	}
+}
+
+// sendGreetingCard sends the user's profile picture with text as caption
+func sendGreetingCard(client *whatsmeow.Client, chat, user types.JID, text string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pic, err := client.GetProfilePictureInfo(ctx, user, &whatsmeow.GetProfilePictureParams{})
+	if err != nil || pic == nil || pic.URL == "" {
+		return false // hidden or no picture
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, pic.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxCardImage))
+	if err != nil || resp.StatusCode != http.StatusOK || len(data) == 0 {
+		return false
+	}
+
+	up, err := client.Upload(ctx, data, whatsmeow.MediaImage)
+	if err != nil {
+		return false
+	}
+	_, err = client.SendMessage(ctx, chat, &waProto.Message{ImageMessage: &waProto.ImageMessage{
+		URL:           proto.String(up.URL),
+		DirectPath:    proto.String(up.DirectPath),
+		MediaKey:      up.MediaKey,
+		Mimetype:      proto.String(http.DetectContentType(data)),
+		FileEncSHA256: up.FileEncSHA256,
+		FileSHA256:    up.FileSHA256,
+		FileLength:    proto.Uint64(uint64(len(data))),
+		Caption:       proto.String(text),
+		ContextInfo:   &waProto.ContextInfo{MentionedJID: []string{user.String()}},
+	}})
+	return err == nil
+}
+
+// ==========================================
+// 🛠️ .welcome / .goodbye COMMANDS
+// ==========================================
+
+// HandleGreeting: .welcome|.goodbye [on|off|set <text>|reset|test] (+ .welcome card on|off)
+func HandleGreeting(c *CmdContext, welcome bool) {
+	chat := c.Msg.Info.Chat.String()
+	name, title, def := "goodbye", "Goodbye", DefaultGoodbyeText
+	if welcome { name, title, def = "welcome", "Welcome", DefaultWelcomeText }
+	text := c.Str("text")
+	save := func(fn func(g *GroupGreeting)) bool {
+		if err := updateGreeting(c.BotID, chat, fn); err != nil {
+			c.Reply("❌ Save failed: " + err.Error())
+			return false
+		}
+		return true
+	}
+
+	switch c.Str("action") {
+	case "", "show":
+		g, err := loadGreeting(c.BotID, chat)
+		if err != nil {
+			c.Reply("❌ Load failed: " + err.Error())
+			return
+		}
+		tmpl, status := g.GoodbyeText, "OFF"
+		if welcome { tmpl = g.WelcomeText }
+		if tmpl == "" { tmpl = def }
+		if g.enabled(c.BotID, welcome) { status = "ON" }
+		msg := fmt.Sprintf("👋 *%s: %s*\n\n%s\n\n🔤 {user} {group} {count} {desc}\n%s%s on|off | set <text> | reset | test",
+			title, status, tmpl, c.Prefix, name)
+		if welcome {
+			card := "OFF"
+			if g.Card { card = "ON" }
+			msg += fmt.Sprintf(" | card on|off\n🖼️ Card: %s", card)
+		}
+		c.Reply(msg)
+
+	case "on", "off":
+		on := c.Str("action") == "on"
+		if save(func(g *GroupGreeting) {
+			if welcome { g.Welcome = &on } else { g.Goodbye = &on }
+		}) {
+			c.Reply(fmt.Sprintf("✅ %s: %s", title, strings.ToUpper(c.Str("action"))))
+		}
+
+	case "set":
+		if text == "" {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %s%s set <text with {user} {group} {count} {desc}>", c.Prefix, name))
+			return
+		}
+		if save(func(g *GroupGreeting) {
+			if welcome { g.WelcomeText = text } else { g.GoodbyeText = text }
+		}) {
+			c.Reply("✅ " + title + " message saved.")
+		}
+
+	case "reset":
+		if save(func(g *GroupGreeting) {
+			if welcome { g.WelcomeText = "" } else { g.GoodbyeText = "" }
+		}) {
+			c.Reply("♻️ " + title + " message reset to default.")
+		}
+
+	case "card":
+		if !welcome || (text != "on" && text != "off") {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %swelcome card on|off", c.Prefix))
+			return
+		}
+		if save(func(g *GroupGreeting) { g.Card = text == "on" }) {
+			c.Reply("🖼️ Welcome card: " + strings.ToUpper(text))
+		}
+
+	case "test":
+		info, err := c.Client.GetGroupInfo(context.Background(), c.Msg.Info.Chat)
+		if err != nil {
+			c.Reply("❌ Can't read group info.")
+			return
+		}
+		g, err := loadGreeting(c.BotID, chat)
+		if err != nil {
+			c.Reply("❌ Load failed: " + err.Error())
+			return
+		}
+		tmpl := g.GoodbyeText
+		if welcome { tmpl = g.WelcomeText }
+		if tmpl == "" { tmpl = def }
+		sender := c.Msg.Info.Sender.ToNonAD()
+		msg := renderGreeting(tmpl, []types.JID{sender}, info)
+		if !welcome || !g.Card || !sendGreetingCard(c.Client, c.Msg.Info.Chat, sender, msg) {
+			sendMention(c.Client, c.Msg.Info.Chat, msg, []types.JID{sender})
+		}
+	}
+}
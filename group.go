@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
-//	"fmt"
+	"fmt"
+	"math/rand"
 	"strings"
+	"time"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
@@ -44,36 +46,333 @@ func GetTarget(v *events.Message, args []string) (types.JID, bool) {
 	return types.EmptyJID, false
 }
 
+// MinNumberDigits is the shortest typed number GetTargets treats as a phone number
+const MinNumberDigits = 7
+
+// GetTargets collects every replied-to user, mention and typed number (deduplicated)
+func GetTargets(v *events.Message, args []string) []types.JID {
+	var out []types.JID
+	seen := make(map[string]bool)
+	add := func(jid types.JID) {
+		jid = jid.ToNonAD()
+		if jid.IsEmpty() || seen[jid.String()] { return }
+		seen[jid.String()] = true
+		out = append(out, jid)
+	}
+
+	if jid, ok := repliedUser(v); ok { add(jid) }
+	for _, m := range v.Message.GetExtendedTextMessage().GetContextInfo().GetMentionedJID() {
+		if jid, err := types.ParseJID(m); err == nil { add(jid) }
+	}
+
+	// Numbers: comma/newline separated; inside one part "+92 300 1234567" is one number
+	digitsOf := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' { return r }
+			return -1
+		}, s)
+	}
+	var words []string
+	for _, a := range args {
+		if !strings.HasPrefix(a, "@") { words = append(words, a) } // mentions are handled above
+	}
+	for _, part := range strings.FieldsFunc(strings.Join(words, " "), func(r rune) bool { return r == ',' || r == '\n' }) {
+		fields := strings.Fields(part)
+		separate := len(fields) > 1
+		for _, f := range fields {
+			if len(digitsOf(f)) < MinNumberDigits { separate = false }
+		}
+		if !separate { fields = []string{part} }
+		for _, f := range fields {
+			if num := digitsOf(f); len(num) >= MinNumberDigits {
+				add(types.NewJID(num, types.DefaultUserServer))
+			}
+		}
+	}
+	return out
+}
+
 // ==========================================
-// 🛡️ ADMIN ACTIONS (Direct Action)
+// 🛡️ ADMIN ACTIONS (bulk, paced)
 // ==========================================
 
+// Large batches and fast repeats get numbers flagged, so changes are split
+// into small batches with a pause between them.
+const (
+	ParticipantBatchSize  = 5
+	ParticipantBatchDelay = 3 * time.Second
+	MaxBulkTargets        = 50
+)
+
+// participantResult is one line of the result table
+type participantResult struct {
+	Target types.JID
+	Status string
+	OK     bool
+	Info   *types.GroupParticipant // server answer, if any (AddRequest lives here)
+}
+
+var participantVerbs = map[whatsmeow.ParticipantChange]string{
+	whatsmeow.ParticipantChangeAdd:     "added",
+	whatsmeow.ParticipantChangeRemove:  "removed",
+	whatsmeow.ParticipantChangePromote: "promoted",
+	whatsmeow.ParticipantChangeDemote:  "demoted",
+}
+
+// participantError turns the per-participant error code into a status
+func participantError(code int) string {
+	switch code {
+	case 401:
+		return "not allowed (blocked the bot?)"
+	case 403:
+		return "privacy-blocked"
+	case 404:
+		return "not in group / not on WhatsApp"
+	case 406:
+		return "not allowed"
+	case 408:
+		return "recently left"
+	case 409:
+		return "already member"
+	case 500:
+		return "group is full"
+	}
+	return fmt.Sprintf("error %d", code)
+}
+
+// bulkParticipants validates targets against the group, then applies action in paced batches
+func bulkParticipants(client *whatsmeow.Client, chat types.JID, targets []types.JID, action whatsmeow.ParticipantChange) ([]participantResult, error) {
+	ctx := context.Background()
+	info, err := client.GetGroupInfo(ctx, chat)
+	if err != nil {
+		return nil, fmt.Errorf("can't read group info")
+	}
+
+	// Index members by canonical identity so LID and phone targets both match
+	members := make(map[string]types.GroupParticipant)
+	for _, p := range info.Participants {
+		rememberIdentity(p.LID, p.PhoneNumber)
+		rememberIdentity(p.JID, p.PhoneNumber)
+		members[resolveIdentity(client, p.JID)] = p
+		if !p.PhoneNumber.IsEmpty() { members[getCleanID(p.PhoneNumber.User)] = p }
+	}
+	botID := getCleanID(client.Store.ID.User)
+	if me, ok := members[botID]; !ok || (!me.IsAdmin && !me.IsSuperAdmin) {
+		return nil, fmt.Errorf("I'm not an admin here")
+	}
+
+	var bans *GroupWarnings
+	if action == whatsmeow.ParticipantChangeAdd {
+		// Without the ban list nobody is added, or a banned user could slip back in
+		if bans, err = loadGroupWarnings(botID, chat.String()); err != nil {
+			return nil, fmt.Errorf("can't read the ban list")
+		}
+	}
+
+	var results []participantResult
+	var pending []types.JID
+	var pendingIdx []int // results index of each pending JID
+	index := make(map[string]int) // identity -> results index (every target, for dedup and answers)
+	for _, t := range targets {
+		id := resolveIdentity(client, t)
+		r := participantResult{Target: t}
+		m, member := members[id]
+		_, dup := index[id]
+		switch {
+		case dup:
+			continue
+		case id == botID:
+			r.Status = "that's me"
+		case action == whatsmeow.ParticipantChangeAdd && member:
+			r.Status = "already member"
+		case action == whatsmeow.ParticipantChangeAdd && bans.Bans[id].Until.After(time.Now()):
+			r.Status = "banned until " + bans.Bans[id].Until.Format("02 Jan 15:04")
+		case action != whatsmeow.ParticipantChangeAdd && !member:
+			r.Status = "not in group"
+		case action != whatsmeow.ParticipantChangeAdd && m.IsSuperAdmin:
+			r.Status = "group creator"
+		case action == whatsmeow.ParticipantChangePromote && m.IsAdmin:
+			r.Status = "already admin"
+		case action == whatsmeow.ParticipantChangeDemote && !m.IsAdmin:
+			r.Status = "not admin"
+		default:
+			if member { t = m.JID } // use the address the group knows
+			r.Target = t
+			pending = append(pending, t)
+			pendingIdx = append(pendingIdx, len(results))
+		}
+		index[id] = len(results)
+		results = append(results, r)
+	}
+
+	for start := 0; start < len(pending); start += ParticipantBatchSize {
+		if start > 0 {
+			time.Sleep(ParticipantBatchDelay + time.Duration(rand.Int63n(int64(time.Second))))
+		}
+		end := min(start+ParticipantBatchSize, len(pending))
+		batch, batchIdx := pending[start:end], pendingIdx[start:end]
+		resp, err := client.UpdateGroupParticipants(ctx, chat, batch, action)
+		if err != nil {
+			fmt.Printf("⚠️ Participant update failed (%s): %v\n", chat, err)
+			for _, idx := range batchIdx {
+				results[idx].Status = "failed: " + err.Error()
+			}
+			continue
+		}
+
+		answered := make(map[int]bool, len(batchIdx)) // only this batch's rows take answers
+		for _, idx := range batchIdx { answered[idx] = false }
+		for i := range resp {
+			p := resp[i]
+			rememberIdentity(p.LID, p.PhoneNumber)
+			idx, ok := index[resolveIdentity(client, p.JID)]
+			if !ok && !p.PhoneNumber.IsEmpty() { idx, ok = index[getCleanID(p.PhoneNumber.User)] }
+			if _, inBatch := answered[idx]; !ok || !inBatch { continue }
+			answered[idx] = true
+			results[idx].Info = &p
+			if p.Error == 0 {
+				results[idx].Status, results[idx].OK = participantVerbs[action], true
+			} else {
+				results[idx].Status = participantError(p.Error)
+			}
+		}
+		for _, idx := range batchIdx {
+			if !answered[idx] { results[idx].Status = "no answer" }
+		}
+	}
+
+	if action == whatsmeow.ParticipantChangePromote || action == whatsmeow.ParticipantChangeDemote {
+		forgetGroupAdmins(client, chat)
+	}
+	return results, nil
+}
+
+// formatParticipantResults renders the per-user table
+func formatParticipantResults(client *whatsmeow.Client, title string, results []participantResult) string {
+	done := 0
+	var sb strings.Builder
+	for _, r := range results {
+		icon := "⚠️"
+		switch {
+		case r.OK:
+			icon = "✅"
+			done++
+		case strings.HasPrefix(r.Status, "privacy-blocked"):
+			icon = "🔒"
+		case strings.HasPrefix(r.Status, "invite sent"):
+			icon = "📨"
+		case strings.HasPrefix(r.Status, "failed"):
+			icon = "❌"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s — %s\n", icon, resolveIdentity(client, r.Target), r.Status))
+	}
+	return fmt.Sprintf("👥 *%s* — %d/%d done\n\n%s", title, done, len(results), strings.TrimRight(sb.String(), "\n"))
+}
+
+// runParticipantCommand is the shared body of kick/add/promote/demote
+func runParticipantCommand(client *whatsmeow.Client, v *events.Message, args []string, action whatsmeow.ParticipantChange, title string) []participantResult {
+	targets := GetTargets(v, args)
+	if len(targets) == 0 {
+		ReplyMessage(client, v, "❌ Mention, reply to or type the numbers.")
+		return nil
+	}
+	if len(targets) > MaxBulkTargets {
+		ReplyMessage(client, v, fmt.Sprintf("⚠️ Max %d users at once.", MaxBulkTargets))
+		return nil
+	}
+	if len(targets) > ParticipantBatchSize {
+		ReplyMessage(client, v, fmt.Sprintf("⏳ %s %d users in batches of %d...", title, len(targets), ParticipantBatchSize))
+	}
+	results, err := bulkParticipants(client, v.Info.Chat, targets, action)
+	if err != nil {
+		ReplyMessage(client, v, "❌ "+err.Error())
+		return nil
+	}
+	return results
+}
+
 func HandleKick(client *whatsmeow.Client, v *events.Message, args []string) {
-	target, found := GetTarget(v, args)
-	if !found { ReplyMessage(client, v, "❌ Select user."); return }
-	_, err := client.UpdateGroupParticipants(context.Background(), v.Info.Chat, []types.JID{target}, whatsmeow.ParticipantChangeRemove)
-	if err != nil { ReplyMessage(client, v, "❌ Failed.") } else { ReplyMessage(client, v, "👢 Kicked!") }
+	if results := runParticipantCommand(client, v, args, whatsmeow.ParticipantChangeRemove, "Kick"); results != nil {
+		ReplyMessage(client, v, formatParticipantResults(client, "👢 Kick", results))
+	}
 }
 
 func HandleAdd(client *whatsmeow.Client, v *events.Message, args []string) {
-	target, found := GetTarget(v, args)
-	if !found { ReplyMessage(client, v, "❌ Provide number."); return }
-	_, err := client.UpdateGroupParticipants(context.Background(), v.Info.Chat, []types.JID{target}, whatsmeow.ParticipantChangeAdd)
-	if err != nil { ReplyMessage(client, v, "❌ Failed.") } else { ReplyMessage(client, v, "➕ Added!") }
+	if results := runParticipantCommand(client, v, args, whatsmeow.ParticipantChangeAdd, "Add"); results != nil {
+		sendAddInvites(client, v.Info.Chat, results)
+		ReplyMessage(client, v, formatParticipantResults(client, "➕ Add", results))
+	}
+}
+
+// ==========================================
+// 📨 INVITE FALLBACK (privacy-blocked adds)
+// ==========================================
+
+// sendAddInvites DMs a group invite to everyone whose privacy settings
+// refused the add. WhatsApp returns a one-off invite code for them; when it
+// doesn't, the group's normal invite link is sent instead.
+func sendAddInvites(client *whatsmeow.Client, chat types.JID, results []participantResult) {
+	ctx := context.Background()
+	groupName, link := "", ""
+	sent := 0
+	for i := range results {
+		r := &results[i]
+		if r.Status != "privacy-blocked" { continue }
+
+		if groupName == "" {
+			groupName = "the group"
+			if info, err := client.GetGroupInfo(ctx, chat); err == nil && info.Name != "" { groupName = info.Name }
+		}
+		if sent > 0 { time.Sleep(time.Second) }
+
+		dm := r.Target
+		if r.Info != nil && !r.Info.PhoneNumber.IsEmpty() { dm = r.Info.PhoneNumber }
+		caption := fmt.Sprintf("👋 You were invited to join *%s*.\nYour privacy settings don't allow being added directly, tap below to join.", groupName)
+
+		var msg *waProto.Message
+		if r.Info != nil && r.Info.AddRequest != nil && r.Info.AddRequest.Code != "" {
+			msg = &waProto.Message{GroupInviteMessage: &waProto.GroupInviteMessage{
+				GroupJID:         proto.String(chat.String()),
+				InviteCode:       proto.String(r.Info.AddRequest.Code),
+				InviteExpiration: proto.Int64(r.Info.AddRequest.Expiration.Unix()),
+				GroupName:        proto.String(groupName),
+				Caption:          proto.String(caption),
+			}}
+		} else {
+			if link == "" {
+				l, err := client.GetGroupInviteLink(ctx, chat, false)
+				if err != nil {
+					r.Status = "privacy-blocked (no invite)"
+					continue
+				}
+				link = l
+			}
+			msg = &waProto.Message{Conversation: proto.String(caption + "\n\n" + link)}
+		}
+
+		if _, err := client.SendMessage(ctx, dm, msg); err != nil {
+			fmt.Printf("⚠️ Invite DM failed (%s): %v\n", dm, err)
+			r.Status = "privacy-blocked (invite failed)"
+			continue
+		}
+		r.Status = "invite sent"
+		if r.Info != nil && r.Info.AddRequest != nil && !r.Info.AddRequest.Expiration.IsZero() {
+			r.Status += ", expires " + r.Info.AddRequest.Expiration.Format("02 Jan 15:04")
+		}
+		sent++
+	}
 }
 
 func HandlePromote(client *whatsmeow.Client, v *events.Message, args []string) {
-	target, found := GetTarget(v, args)
-	if !found { ReplyMessage(client, v, "❌ Select user."); return }
-	_, err := client.UpdateGroupParticipants(context.Background(), v.Info.Chat, []types.JID{target}, whatsmeow.ParticipantChangePromote)
-	if err != nil { ReplyMessage(client, v, "❌ Failed.") } else { ReplyMessage(client, v, "⬆️ Promoted!") }
+	if results := runParticipantCommand(client, v, args, whatsmeow.ParticipantChangePromote, "Promote"); results != nil {
+		ReplyMessage(client, v, formatParticipantResults(client, "⬆️ Promote", results))
+	}
 }
 
 func HandleDemote(client *whatsmeow.Client, v *events.Message, args []string) {
-	target, found := GetTarget(v, args)
-	if !found { ReplyMessage(client, v, "❌ Select user."); return }
-	_, err := client.UpdateGroupParticipants(context.Background(), v.Info.Chat, []types.JID{target}, whatsmeow.ParticipantChangeDemote)
-	if err != nil { ReplyMessage(client, v, "❌ Failed.") } else { ReplyMessage(client, v, "⬇️ Demoted!") }
+	if results := runParticipantCommand(client, v, args, whatsmeow.ParticipantChangeDemote, "Demote"); results != nil {
+		ReplyMessage(client, v, formatParticipantResults(client, "⬇️ Demote", results))
+	}
 }
 
 func HandleGroupSettings(client *whatsmeow.Client, v *events.Message, args []string) {
@@ -101,10 +400,10 @@ func HandleDelete(client *whatsmeow.Client, v *events.Message) {
 	if err != nil { ReplyMessage(client, v, "❌ Failed.") }
 }
 
-func HandleTagAll(client *whatsmeow.Client, v *events.Message, args []string) {
+func HandleTagAll(client *whatsmeow.Client, v *events.Message, text string) {
 	groupInfo, err := client.GetGroupInfo(context.Background(), v.Info.Chat)
 	if err != nil { ReplyMessage(client, v, "❌ Error."); return }
-	text := "📣 *EVERYONE* " + strings.Join(args, " ")
+	text = "📣 *EVERYONE* " + text
 	var mentions []string
 	for _, p := range groupInfo.Participants { mentions = append(mentions, p.JID.String()) }
 	client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
@@ -115,10 +414,9 @@ func HandleTagAll(client *whatsmeow.Client, v *events.Message, args []string) {
 	})
 }
 
-func HandleHideTag(client *whatsmeow.Client, v *events.Message, args []string) {
+func HandleHideTag(client *whatsmeow.Client, v *events.Message, text string) {
 	groupInfo, err := client.GetGroupInfo(context.Background(), v.Info.Chat)
 	if err != nil { ReplyMessage(client, v, "❌ Error."); return }
-	text := strings.Join(args, " ")
 	if text == "" { text = "🔔" }
 	var mentions []string
 	for _, p := range groupInfo.Participants { mentions = append(mentions, p.JID.String()) }
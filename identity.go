@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 🪪 IDENTITY RESOLVER (LID <-> phone)
+// ==========================================
+
+// Every sender is reduced to one canonical identity: the phone number when
+// it is known, otherwise the bare LID user. Device suffixes are dropped.
+
+const GroupAdminCacheTTL = 1 * time.Minute
+
+var (
+	// lid user -> phone user (mappings never change, so no expiry)
+	identityCache = make(map[string]string)
+	identityMutex sync.RWMutex
+
+	groupAdminCache = make(map[string]groupAdminEntry)
+	groupAdminMutex sync.Mutex
+)
+
+type groupAdminEntry struct {
+	Admins  map[string]bool // canonical identities
+	Fetched time.Time
+}
+
+func rememberIdentity(lid, pn types.JID) {
+	if lid.Server != types.HiddenUserServer || pn.Server != types.DefaultUserServer {
+		return
+	}
+	identityMutex.Lock()
+	identityCache[lid.User] = pn.User
+	identityMutex.Unlock()
+}
+
+// resolveIdentity maps any JID (phone, LID, device-suffixed) to its canonical identity
+func resolveIdentity(client *whatsmeow.Client, jid types.JID) string {
+	jid = jid.ToNonAD()
+	if jid.Server != types.HiddenUserServer {
+		return getCleanID(jid.User)
+	}
+
+	// Bot's own LID
+	if client.Store.ID != nil && client.Store.LID.User == jid.User {
+		return getCleanID(client.Store.ID.User)
+	}
+
+	identityMutex.RLock()
+	pn, ok := identityCache[jid.User]
+	identityMutex.RUnlock()
+	if ok {
+		return pn
+	}
+
+	// whatsmeow keeps the LID<->PN map it learns from the server
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if pnJID, err := client.Store.LIDs.GetPNForLID(ctx, jid); err == nil && !pnJID.IsEmpty() {
+		rememberIdentity(jid, pnJID)
+		return pnJID.User
+	}
+
+	// Bots paired on this server (lid_storage)
+	lidMutex.RLock()
+	defer lidMutex.RUnlock()
+	for phone, lid := range lidCache {
+		if getCleanID(lid) == jid.User {
+			return phone
+		}
+	}
+	return jid.User
+}
+
+// senderIdentity prefers the alternate address WhatsApp sends along with the message
+func senderIdentity(client *whatsmeow.Client, v *events.Message) string {
+	rememberIdentity(v.Info.Sender.ToNonAD(), v.Info.SenderAlt.ToNonAD())
+	return resolveIdentity(client, v.Info.Sender)
+}
+
+// sameIdentity: do both JIDs point to the same person?
+func sameIdentity(client *whatsmeow.Client, a, b types.JID) bool {
+	return resolveIdentity(client, a) == resolveIdentity(client, b)
+}
+
+// isBotOwner is the single owner check used by permissions and owner commands
+func isBotOwner(client *whatsmeow.Client, sender types.JID) bool {
+	return isOwnerByLID(client, sender) || isOwner(client, sender)
+}
+
+// isGroupAdmin compares canonical identities, so LID and phone participants both match
+func isGroupAdmin(client *whatsmeow.Client, chat, sender types.JID) bool {
+	key := getCleanID(client.Store.ID.User) + "|" + chat.String()
+	id := resolveIdentity(client, sender)
+
+	groupAdminMutex.Lock()
+	entry, ok := groupAdminCache[key]
+	groupAdminMutex.Unlock()
+	if ok && time.Since(entry.Fetched) < GroupAdminCacheTTL {
+		return entry.Admins[id]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info, err := client.GetGroupInfo(ctx, chat)
+	if err != nil {
+		return isAdmin(client, chat, sender)
+	}
+
+	admins := make(map[string]bool)
+	for _, p := range info.Participants {
+		if !p.IsAdmin && !p.IsSuperAdmin {
+			continue
+		}
+		rememberIdentity(p.LID, p.PhoneNumber)
+		rememberIdentity(p.JID, p.PhoneNumber)
+		admins[resolveIdentity(client, p.JID)] = true
+		if !p.PhoneNumber.IsEmpty() {
+			admins[getCleanID(p.PhoneNumber.User)] = true
+		}
+	}
+
+	groupAdminMutex.Lock()
+	groupAdminCache[key] = groupAdminEntry{Admins: admins, Fetched: time.Now()}
+	groupAdminMutex.Unlock()
+	return admins[id]
+}
+
+// forgetGroupAdmins drops the cache after promote/demote
+func forgetGroupAdmins(client *whatsmeow.Client, chat types.JID) {
+	groupAdminMutex.Lock()
+	delete(groupAdminCache, getCleanID(client.Store.ID.User)+"|"+chat.String())
+	groupAdminMutex.Unlock()
+}
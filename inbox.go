@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 📥 INBOX MESSAGE SHAPES
+// ==========================================
+
+// StoredMessage: وہ شکل جو lists.html کو چاہیے
+type StoredMessage struct {
+	MessageID    string `json:"message_id"`
+	BotID        string `json:"bot_id"`
+	ChatID       string `json:"chat_id"`
+	Sender       string `json:"sender"`
+	SenderName   string `json:"sender_name,omitempty"`
+	IsFromMe     bool   `json:"is_from_me"`
+	IsGroup      bool   `json:"is_group"`
+	Type         string `json:"type"`
+	Content      string `json:"content"`
+	IsSticker    bool   `json:"is_sticker,omitempty"`
+	MediaMime    string `json:"media_mime,omitempty"`
+	MediaSize    uint64 `json:"media_size,omitempty"`
+	QuotedID     string `json:"quoted_id,omitempty"`
+	QuotedMsg    string `json:"quoted_msg,omitempty"`
+	QuotedSender string `json:"quoted_sender,omitempty"`
+	Timestamp    int64  `json:"timestamp"`
+
+	raw *waProto.Message
+}
+
+type ChatSummary struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	LastMessage   string `json:"last_message,omitempty"`
+	LastMessageAt int64  `json:"last_message_at"`
+}
+
+// Cursor is the (timestamp, id) of the last row of a page. Pages run newest
+// first and the next one starts strictly below the cursor, so rows that share
+// a timestamp (Unix milliseconds) with the cut row are not skipped.
+type Cursor struct {
+	Timestamp int64
+	ID        string
+}
+
+func (c Cursor) IsZero() bool { return c.Timestamp <= 0 }
+
+// String is the X-Next-Cursor / ?before= form: "<timestamp>:<id>"
+func (c Cursor) String() string {
+	return strconv.FormatInt(c.Timestamp, 10) + ":" + c.ID
+}
+
+// Admits reports whether the row (ts, id) comes after the cursor
+func (c Cursor) Admits(ts int64, id string) bool {
+	return c.IsZero() || ts < c.Timestamp || (ts == c.Timestamp && id < c.ID)
+}
+
+// parseCursor also accepts a bare timestamp (everything older than it)
+func parseCursor(raw string) (Cursor, error) {
+	tsRaw, id, _ := strings.Cut(raw, ":")
+	ts, err := strconv.ParseInt(tsRaw, 10, 64)
+	if err != nil || ts <= 0 {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+	return Cursor{Timestamp: ts, ID: id}, nil
+}
+
+// ==========================================
+// 🧩 MESSAGE CONVERSION
+// ==========================================
+
+func buildStoredMessage(botID string, v *events.Message) *StoredMessage {
+	if v.Message == nil {
+		return nil
+	}
+	msgType, content, isSticker := describeMessage(v.Message)
+	if msgType == "" {
+		return nil
+	}
+
+	msg := &StoredMessage{
+		MessageID:  string(v.Info.ID),
+		BotID:      botID,
+		ChatID:     v.Info.Chat.String(),
+		Sender:     v.Info.Sender.ToNonAD().String(),
+		SenderName: v.Info.PushName,
+		IsFromMe:   v.Info.IsFromMe,
+		IsGroup:    v.Info.IsGroup,
+		Type:       msgType,
+		Content:    content,
+		IsSticker:  isSticker,
+		Timestamp:  v.Info.Timestamp.UnixMilli(),
+		raw:        v.Message,
+	}
+	if msgType != "text" {
+		msg.MediaMime = mediaMimetype(v.Message)
+		msg.MediaSize = mediaLength(v.Message)
+	}
+
+	if ctx := contextInfoOf(v.Message); ctx != nil && ctx.GetStanzaID() != "" {
+		msg.QuotedID = ctx.GetStanzaID()
+		msg.QuotedSender = ctx.GetParticipant()
+		if ctx.QuotedMessage != nil {
+			msg.QuotedMsg = getText(ctx.QuotedMessage)
+		}
+	}
+	return msg
+}
+
+// describeMessage: type, text content and sticker flag. Empty type = not stored.
+func describeMessage(m *waProto.Message) (string, string, bool) {
+	switch {
+	case m.Conversation != nil || m.ExtendedTextMessage != nil:
+		return "text", getText(m), false
+	case m.ImageMessage != nil:
+		return "image", "MEDIA_WAITING", false
+	case m.StickerMessage != nil:
+		return "image", "MEDIA_WAITING", true
+	case m.VideoMessage != nil:
+		return "video", "MEDIA_WAITING", false
+	case m.AudioMessage != nil:
+		return "audio", "MEDIA_WAITING", false
+	case m.DocumentMessage != nil:
+		return "document", "MEDIA_WAITING", false
+	}
+	return "", "", false
+}
+
+func contextInfoOf(m *waProto.Message) *waProto.ContextInfo {
+	switch {
+	case m.ExtendedTextMessage != nil:
+		return m.ExtendedTextMessage.ContextInfo
+	case m.ImageMessage != nil:
+		return m.ImageMessage.ContextInfo
+	case m.VideoMessage != nil:
+		return m.VideoMessage.ContextInfo
+	case m.AudioMessage != nil:
+		return m.AudioMessage.ContextInfo
+	case m.StickerMessage != nil:
+		return m.StickerMessage.ContextInfo
+	case m.DocumentMessage != nil:
+		return m.DocumentMessage.ContextInfo
+	}
+	return nil
+}
+
+func chatType(chatID string) string {
+	switch {
+	case chatID == "status@broadcast":
+		return "status"
+	case strings.HasSuffix(chatID, "@g.us"):
+		return "group"
+	}
+	return "user"
+}
+
+func mediaMimetype(m *waProto.Message) string {
+	var mime string
+	switch {
+	case m.ImageMessage != nil:
+		mime = m.ImageMessage.GetMimetype()
+	case m.StickerMessage != nil:
+		mime = m.StickerMessage.GetMimetype()
+	case m.VideoMessage != nil:
+		mime = m.VideoMessage.GetMimetype()
+	case m.AudioMessage != nil:
+		mime = m.AudioMessage.GetMimetype()
+	case m.DocumentMessage != nil:
+		mime = m.DocumentMessage.GetMimetype()
+	}
+	if mime == "" {
+		mime = "application/octet-stream"
+	}
+	return mime
+}
+
+func mediaLength(m *waProto.Message) uint64 {
+	switch {
+	case m.ImageMessage != nil:
+		return m.ImageMessage.GetFileLength()
+	case m.StickerMessage != nil:
+		return m.StickerMessage.GetFileLength()
+	case m.VideoMessage != nil:
+		return m.VideoMessage.GetFileLength()
+	case m.AudioMessage != nil:
+		return m.AudioMessage.GetFileLength()
+	case m.DocumentMessage != nil:
+		return m.DocumentMessage.GetFileLength()
+	}
+	return 0
+}
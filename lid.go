@@ -1,10 +1,8 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"sync"
 	"time"
 
@@ -13,6 +11,7 @@ import (
 	"go.mau.fi/whatsmeow/types/events"
 )
 
+// Legacy file, only read once by migrateLegacyFiles()
 const LIDDataFile = "/data/lid_storage.json"
 
 type BotLIDData struct {
@@ -42,9 +41,11 @@ func OnNewPairing(client *whatsmeow.Client) {
 	if client.Store.ID == nil { return }
 	phone := getCleanID(client.Store.ID.User)
 	
-	// Check if we are logged in with LID
+	// The device store keeps the account LID next to the phone JID
 	var lid string
-	if client.Store.ID.Server == "lid" {
+	if !client.Store.LID.IsEmpty() {
+		lid = client.Store.LID.User
+	} else if client.Store.ID.Server == types.HiddenUserServer {
 		lid = client.Store.ID.User
 	}
 	
@@ -52,37 +53,28 @@ func OnNewPairing(client *whatsmeow.Client) {
 	if lid != "" {
 		lidMutex.Lock()
 		lidCache[phone] = lid
-		
-		// Update File
-		data, _ := readJSON()
-		if data.Bots == nil { data.Bots = make(map[string]BotLIDData) }
-		data.Bots[phone] = BotLIDData{Phone: phone, LID: lid, ExtractedAt: time.Now()}
-		data.LastUpdate = time.Now()
-		saveJSON(data)
-		
 		lidMutex.Unlock()
+
+		// Persist in Store
+		dataStore.SaveLID(context.Background(), BotLIDData{Phone: phone, LID: lid, ExtractedAt: time.Now()})
 		fmt.Printf("✅ Saved LID for %s\n", phone)
 	}
 }
 
+// forgetLID drops the cached LID of a removed bot
+func forgetLID(phone string) {
+	lidMutex.Lock()
+	delete(lidCache, phone)
+	lidMutex.Unlock()
+}
+
+// isOwnerByLID: sender is the bot's own account, whether it writes as phone, LID or another device
+// or one of the configured owners (BotSettings.Owners)
 func isOwnerByLID(client *whatsmeow.Client, sender types.JID) bool {
 	if client.Store.ID == nil { return false }
-	botPhone := getCleanID(client.Store.ID.User)
-	senderPhone := getCleanID(sender.User)
-	
-	// Simple check: Is the sender the bot itself? (Self-message)
-	if senderPhone == botPhone { return true }
-	
-	// Cache Check
-	lidMutex.RLock()
-	cachedLID, exists := lidCache[botPhone]
-	lidMutex.RUnlock()
-	
-	if exists && getCleanID(sender.User) == getCleanID(cachedLID) {
-		return true
-	}
-	
-	return false
+	botID := getCleanID(client.Store.ID.User)
+	id := resolveIdentity(client, sender)
+	return id == botID || isListedOwner(botID, id)
 }
 
 func sendOwnerStatus(client *whatsmeow.Client, v *events.Message) {
@@ -90,28 +82,13 @@ func sendOwnerStatus(client *whatsmeow.Client, v *events.Message) {
 }
 
 func loadLIDFile() {
-	data, err := readJSON()
+	lids, err := dataStore.LoadLIDs(context.Background())
 	if err == nil {
 		lidMutex.Lock()
-		for p, info := range data.Bots {
+		for p, info := range lids {
 			lidCache[p] = info.LID
 		}
 		lidMutex.Unlock()
-		fmt.Printf("📂 Loaded %d LIDs.\n", len(data.Bots))
+		fmt.Printf("📂 Loaded %d LIDs.\n", len(lids))
 	}
 }
-
-func readJSON() (LIDStorage, error) {
-	var data LIDStorage
-	file, err := os.ReadFile(LIDDataFile)
-	if err != nil { return data, err }
-	json.Unmarshal(file, &data)
-	return data, nil
-}
-
-func saveJSON(data LIDStorage) {
-	dir := filepath.Dir(LIDDataFile)
-	os.MkdirAll(dir, 0755)
-	bytes, _ := json.MarshalIndent(data, "", "  ")
-	os.WriteFile(LIDDataFile, bytes, 0644)
-}
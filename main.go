@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -36,10 +35,12 @@ var (
 	sm = &SessionManager{
 		Clients:  make(map[string]*whatsmeow.Client),
 		Settings: make(map[string]*BotSettings),
+
+		lifecycles: make(map[string]context.CancelFunc),
 	}
 	container *sqlstore.Container
-	upgrader  = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
-	wsClients = make(map[*websocket.Conn]bool)
+	upgrader  = websocket.Upgrader{CheckOrigin: checkWSOrigin}
+	wsClients = make(map[*websocket.Conn]*Principal)
 	wsMutex   sync.Mutex
 )
 
@@ -56,6 +57,9 @@ func main() {
 
 	// 2. Initialize Components
 	initDB()
+	InitStore()
+	InitAuth()
+	InitTenants()
 	InitLIDSystem()
 	loadSettings()
 
@@ -64,6 +68,7 @@ func main() {
 
 	// 4. Background Tasks
 	go autoSaveLoop()
+	go moderationSweepLoop()
 
 	// 5. Start Server
 	setupRoutes()
@@ -80,7 +85,9 @@ func initDB() {
 	dbLog := waLog.Stdout("Database", "WARN", true) 
 	var err error
 	
-	container, err = sqlstore.New(context.Background(), "sqlite3", "file:"+dbPath+"?_foreign_keys=on", dbLog)
+	// Same options as the Store's pool on this file (see newSQLiteStore), so
+	// archive writes wait for whatsmeow's lock instead of failing
+	container, err = sqlstore.New(context.Background(), "sqlite3", "file:"+dbPath+SQLiteOptions, dbLog)
 	if err != nil {
 		log.Fatalf("❌ SQLite Init Failed: %v", err)
 	}
@@ -99,13 +106,25 @@ func setupRoutes() {
 	})
 	http.HandleFunc("/ws", handleWebSocket)
 	http.HandleFunc("/api/pair", handlePair)
+	http.HandleFunc("GET /api/pair/{id}", handlePairStatus)
+	http.HandleFunc("DELETE /api/pair/{id}", handlePairCancel)
+	http.HandleFunc("GET /api/pair/{id}/qr.png", handlePairQR)
+	http.HandleFunc("DELETE /api/sessions/{id}", handleSessionRemove)
+	http.HandleFunc("GET /api/sessions/{id}/owners", handleListOwners)
+	http.HandleFunc("POST /api/sessions/{id}/owners", handleAddOwner)
+	http.HandleFunc("DELETE /api/sessions/{id}/owners/{owner}", handleDeleteOwner)
+	setupInboxRoutes()
+	setupAuthRoutes()
+	setupTenantRoutes()
+	setupBrandingRoutes()
 }
 
 func startServer() {
 	port := os.Getenv("PORT")
 	if port == "" { port = Port }
 
-	server := &http.Server{Addr: ":" + port}
+	// Every route goes through the auth layer (see auth.go)
+	server := &http.Server{Addr: ":" + port, Handler: withAuth(http.DefaultServeMux)}
 	
 	go func() {
 		fmt.Printf("🌐 Server Live on Port %s\n", port)
@@ -122,10 +141,12 @@ func startServer() {
 	fmt.Println("\n🛑 Shutting down...")
 	saveSettings()
 	sm.mu.Lock()
-	for _, client := range sm.Clients {
+	for botID, client := range sm.Clients {
+		sm.stopLifecycleLocked(botID)
 		client.Disconnect()
 	}
 	sm.mu.Unlock()
+	dataStore.Close()
 	fmt.Println("👋 Goodbye!")
 }
 
@@ -161,27 +182,72 @@ func connectBot(device *store.Device, botID string) {
 		HandleMessages(client, evt)
 	})
 
-	if err := client.Connect(); err != nil {
-		fmt.Printf("❌ Failed to connect %s: %v\n", botID, err)
-		return
-	}
+	sm.mu.Lock()
+	sm.Clients[botID] = client
+	ctx := sm.startLifecycleLocked(botID)
+	sm.mu.Unlock()
+
+	// Supervisor does the first connect and retries with backoff
+	superviseBot(ctx, client, botID)
+	startBotLoops(ctx, client, botID)
+}
+
+// adoptPairedClient: نئی pairing والا client پرانے کی جگہ لیتا ہے (atomic swap)
+func adoptPairedClient(client *whatsmeow.Client, botID string) {
+	client.AddEventHandler(func(evt interface{}) {
+		HandleMessages(client, evt)
+	})
 
 	sm.mu.Lock()
+	old := sm.Clients[botID]
 	sm.Clients[botID] = client
+	if _, ok := sm.Settings[botID]; !ok {
+		sm.Settings[botID] = &BotSettings{Prefix: ".", AlwaysOnline: true, Mode: "public"}
+	}
+	// New lifecycle cancels the old client's loops before its socket is closed
+	ctx := sm.startLifecycleLocked(botID)
 	sm.mu.Unlock()
-	
+
+	superviseBot(ctx, client, botID)
+
+	// Old device goes only after the new one is live
+	if old != nil && old != client {
+		old.Disconnect()
+		fmt.Println("🧹 [DEBUG] Disconnected old RAM session")
+	}
+	removeStaleDevices(botID, *client.Store.ID)
+
+	startBotLoops(ctx, client, botID)
+}
+
+// removeStaleDevices deletes every stored device of botID except keep
+func removeStaleDevices(botID string, keep types.JID) {
+	if n := deleteBotDevices(botID, keep); n > 0 {
+		fmt.Printf("🧹 [DEBUG] Deleted %d old DB session(s)\n", n)
+	}
+}
+
+// startBotLoops runs per-bot workers until ctx (the bot lifecycle) ends
+func startBotLoops(ctx context.Context, client *whatsmeow.Client, botID string) {
 	fmt.Printf("✅ Bot Online: %s\n", botID)
 	broadcastWS(WSMessage{Type: "new_session", BotID: botID})
+	resumeMutes(ctx, client, botID)
 	
 	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
 		for {
-			time.Sleep(1 * time.Minute)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
 			if client.IsConnected() {
 				sm.mu.RLock()
 				settings := sm.Settings[botID]
 				sm.mu.RUnlock()
 				if settings != nil && settings.AlwaysOnline {
-					client.SendPresence(context.Background(), types.PresenceAvailable)
+					client.SendPresence(ctx, types.PresenceAvailable)
 				}
 			}
 		}
@@ -189,163 +255,64 @@ func connectBot(device *store.Device, botID string) {
 }
 
 // ==========================================
-// 💾 PERSISTENCE LOGIC
+// 🔁 BOT LIFECYCLE
 // ==========================================
 
-func loadSettings() {
-	path := filepath.Join(VolumeDir, SettingsFile)
-	data, err := os.ReadFile(path)
-	if err == nil {
-		sm.mu.Lock()
-		json.Unmarshal(data, &sm.Settings)
-		sm.mu.Unlock()
-		fmt.Println("📂 Settings Loaded into RAM.")
-	}
-}
-
-func saveSettings() {
-	sm.mu.RLock()
-	data, err := json.MarshalIndent(sm.Settings, "", "  ")
-	sm.mu.RUnlock()
-	if err == nil {
-		os.WriteFile(filepath.Join(VolumeDir, SettingsFile), data, 0644)
-	}
+// startLifecycleLocked gives botID a fresh context, cancelling the previous one.
+// Caller must hold sm.mu.
+func (m *SessionManager) startLifecycleLocked(botID string) context.Context {
+	m.stopLifecycleLocked(botID)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.lifecycles[botID] = cancel
+	return ctx
 }
 
-func autoSaveLoop() {
-	for range time.Tick(30 * time.Second) {
-		saveSettings()
+// stopLifecycleLocked stops every worker of botID. Caller must hold sm.mu.
+func (m *SessionManager) stopLifecycleLocked(botID string) {
+	if cancel, ok := m.lifecycles[botID]; ok {
+		cancel()
+		delete(m.lifecycles, botID)
 	}
 }
 
 // ==========================================
-// 🌐 PAIRING LOGIC (WITH HEAVY DEBUGGING)
+// 💾 PERSISTENCE LOGIC
 // ==========================================
 
-func handlePair(w http.ResponseWriter, r *http.Request) {
-	// ✅ Force Headers
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	// 🔍 LOG 1: REQUEST RECEIVED
-	fmt.Println("\n🟡 [DEBUG] Received Pairing Request...")
-
-	if r.Method != http.MethodPost {
-		fmt.Println("❌ [DEBUG] Invalid Method")
-		w.WriteHeader(405)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
-		return
-	}
-
-	var req PairRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		fmt.Println("❌ [DEBUG] Invalid JSON Body")
-		w.WriteHeader(400)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON data"})
-		return
-	}
-
-	// 🔍 LOG 2: NUMBER PROCESSING
-	rawNum := req.Number
-	number := strings.ReplaceAll(rawNum, "+", "")
-	number = strings.ReplaceAll(number, " ", "")
-	number = strings.ReplaceAll(number, "-", "")
-	cleanID := getCleanID(number)
-
-	fmt.Printf("🔍 [DEBUG] Raw Number: '%s' -> Clean Number: '%s'\n", rawNum, number)
-
-	// Check if number is valid (Basic check)
-	if len(number) < 10 {
-		fmt.Println("❌ [DEBUG] Number too short!")
-		w.WriteHeader(400)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Number too short/invalid format"})
+func loadSettings() {
+	settings, err := dataStore.LoadSettings(context.Background())
+	if err != nil {
+		fmt.Printf("⚠️ Settings Load Failed: %v\n", err)
 		return
 	}
-
-	// 3. Delete existing session
 	sm.mu.Lock()
-	if c, ok := sm.Clients[cleanID]; ok {
-		c.Disconnect()
-		delete(sm.Clients, cleanID)
-		fmt.Println("🧹 [DEBUG] Disconnected old RAM session")
+	for botID, s := range settings {
+		sm.Settings[botID] = s
 	}
 	sm.mu.Unlock()
+	fmt.Println("📂 Settings Loaded into RAM.")
+}
 
-	devices, _ := container.GetAllDevices(context.Background())
-	for _, dev := range devices {
-		if getCleanID(dev.ID.User) == cleanID {
-			dev.Delete(context.Background())
-			fmt.Println("🧹 [DEBUG] Deleted old DB session")
-		}
+func saveSettings() {
+	sm.mu.RLock()
+	snapshot := make(map[string]BotSettings, len(sm.Settings))
+	for botID, s := range sm.Settings {
+		snapshot[botID] = s.clone()
 	}
+	sm.mu.RUnlock()
 
-	// 4. Create Client
-	fmt.Println("⚙️ [DEBUG] Creating new WhatsMeow Client...")
-	device := container.NewDevice()
-	
-	// 🔥 ENABLE DEBUG LOGS FOR WHATSMEOW
-	client := whatsmeow.NewClient(device, waLog.Stdout("Pairing", "DEBUG", true))
-	
-	if err := client.Connect(); err != nil {
-		fmt.Printf("❌ [DEBUG] Connection Failed: %v\n", err)
-		w.WriteHeader(500)
-		json.NewEncoder(w).Encode(map[string]string{"error": "WhatsApp Connect Failed: " + err.Error()})
-		return
+	for botID, s := range snapshot {
+		s := s
+		if err := dataStore.SaveSettings(context.Background(), botID, &s); err != nil {
+			fmt.Printf("⚠️ Settings Save Failed (%s): %v\n", botID, err)
+		}
 	}
-	fmt.Println("✅ [DEBUG] Connected to WhatsApp Server")
-
-	// ⏳ Stability Wait
-	time.Sleep(2 * time.Second)
+}
 
-	// 5. Generate Code
-	fmt.Printf("📲 [DEBUG] Requesting Pairing Code for: %s\n", number)
-	
-	// 🔥 Try different client type if Chrome fails
-	code, err := client.PairPhone(context.Background(), number, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
-	
-	if err != nil {
-		fmt.Printf("❌ [DEBUG] PairPhone Error: %v\n", err)
-		client.Disconnect()
-		// Send exact error to frontend
-		w.WriteHeader(500)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Pairing Failed: " + err.Error()})
-		return
+func autoSaveLoop() {
+	for range time.Tick(30 * time.Second) {
+		saveSettings()
 	}
-
-	fmt.Printf("✅ [DEBUG] Code Generated: %s\n", code)
-
-	// 6. Background Wait Loop
-	go func() {
-		fmt.Println("⏳ [DEBUG] Waiting for user to enter code...")
-		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-		defer cancel()
-		
-		for {
-			select {
-			case <-ctx.Done():
-				if client.Store.ID == nil {
-					fmt.Println("❌ [DEBUG] Pairing Timed Out")
-					client.Disconnect()
-				}
-				return
-			default:
-				if client.Store.ID != nil {
-					fmt.Printf("🎉 [DEBUG] SUCCESS! User %s logged in.\n", cleanID)
-					connectBot(device, cleanID)
-					go OnNewPairing(client)
-					return
-				}
-				time.Sleep(1 * time.Second)
-			}
-		}
-	}()
-
-	// 7. Success Response
-	json.NewEncoder(w).Encode(map[string]string{
-		"code":    code,
-		"success": "true",
-		"number":  cleanID,
-	})
 }
 
 // ==========================================
@@ -353,15 +320,19 @@ func handlePair(w http.ResponseWriter, r *http.Request) {
 // ==========================================
 
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	p := principalFrom(r)
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil { return }
 	
 	wsMutex.Lock()
-	wsClients[conn] = true
+	wsClients[conn] = p
 	wsMutex.Unlock()
 
+	active := 0
 	sm.mu.RLock()
-	active := len(sm.Clients)
+	for id := range sm.Clients {
+		if p.CanAccessBot(id) { active++ }
+	}
 	sm.mu.RUnlock()
 	
 	conn.WriteJSON(WSMessage{Type: "stats", ActiveBots: active})
@@ -376,10 +347,19 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// broadcastWS sends msg only to viewers of its tenant (admins get everything)
 func broadcastWS(msg WSMessage) {
+	tenant := msg.Tenant
+	if tenant == "" && msg.BotID != "" {
+		tenant = botTenant(msg.BotID)
+	}
+
 	wsMutex.Lock()
 	defer wsMutex.Unlock()
-	for conn := range wsClients {
+	for conn, p := range wsClients {
+		if tenant != "" && !p.CanAccessTenant(tenant) {
+			continue
+		}
 		conn.WriteJSON(msg)
 	}
 }
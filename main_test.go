@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// newTestClient returns an unpaired client: nothing dials out, but
+// Remove can disconnect it and look up its devices like a real one
+func newTestClient() *whatsmeow.Client {
+	return whatsmeow.NewClient(container.NewDevice(), waLog.Noop)
+}
+
+// startTestBot is connectBot without the network: the client is registered,
+// gets a lifecycle, a supervisor that is never woken and the per-bot loops
+func startTestBot(client *whatsmeow.Client, botID string) {
+	sm.mu.Lock()
+	sm.Clients[botID] = client
+	ctx := sm.startLifecycleLocked(botID)
+	sm.mu.Unlock()
+
+	sup := &BotSupervisor{
+		ConnStatus: ConnStatus{BotID: botID, State: ConnConnected, Since: time.Now()},
+		client:     client,
+		ctx:        ctx,
+		wake:       make(chan time.Duration, 1),
+	}
+	supervisorMu.Lock()
+	supervisors[botID] = sup
+	supervisorMu.Unlock()
+	go sup.loop()
+
+	startBotLoops(ctx, client, botID)
+}
+
+// waitForGoroutines polls until the count drops to want (workers exit asynchronously)
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			n := runtime.Stack(buf, true)
+			t.Fatalf("%d goroutines running, want %d\n%s", runtime.NumGoroutine(), want, buf[:n])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRemoveStopsBotWorkers(t *testing.T) {
+	var err error
+	dataStore = newMemoryStore()
+	container, err = sqlstore.New(context.Background(), "sqlite3", "file:"+filepath.Join(t.TempDir(), DBName)+SQLiteOptions, waLog.Noop)
+	if err != nil {
+		t.Fatalf("sqlstore: %v", err)
+	}
+	if err := container.Upgrade(context.Background()); err != nil {
+		t.Fatalf("sqlstore upgrade: %v", err)
+	}
+
+	const bots, perBot = 5, 2 // presence ticker + supervisor loop
+	ids := make([]string, bots)
+	clients := make([]*whatsmeow.Client, bots)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("92300000000%d", i)
+		clients[i] = newTestClient()
+		// A pending mute gives every bot a reopen timer too
+		putJSON(bucketMutes, ids[i], map[string]time.Time{"120363000000000000@g.us": time.Now().Add(time.Hour)})
+	}
+	baseline := runtime.NumGoroutine()
+
+	for i, id := range ids {
+		startTestBot(clients[i], id)
+	}
+	if n := runtime.NumGoroutine(); n < baseline+bots*perBot {
+		t.Fatalf("%d goroutines after start, want at least %d", n, baseline+bots*perBot)
+	}
+
+	// Re-pairing gives the bot a new lifecycle; the old workers must go
+	startTestBot(clients[0], ids[0])
+	waitForGoroutines(t, baseline+bots*perBot)
+
+	for _, id := range ids {
+		if _, err := sm.Remove(id, RemoveOptions{}); err != nil {
+			t.Errorf("Remove(%s): %v", id, err)
+		}
+	}
+	waitForGoroutines(t, baseline)
+
+	sm.mu.RLock()
+	live, lifecycles := len(sm.Clients), len(sm.lifecycles)
+	sm.mu.RUnlock()
+	if live != 0 || lifecycles != 0 {
+		t.Errorf("%d clients and %d lifecycles left after Remove", live, lifecycles)
+	}
+	for _, id := range ids {
+		if getSupervisor(id) != nil {
+			t.Errorf("supervisor of %s still registered", id)
+		}
+		muteMutex.Lock()
+		armed := mutes[id]
+		muteMutex.Unlock()
+		if armed != nil {
+			t.Errorf("%s still holds mute timers", id)
+		}
+		if ok, _ := getJSON(bucketMutes, id, &map[string]time.Time{}); ok {
+			t.Errorf("pending mutes of %s survived Remove", id)
+		}
+	}
+}
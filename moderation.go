@@ -0,0 +1,637 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// ==========================================
+// 🚨 GROUP MODERATION (anti-link / spam / flood)
+// ==========================================
+
+// Every group message passes moderateMessage before command handling.
+// Admins, moderators and owners are never touched.
+
+type ModAction string
+
+const (
+	ModDelete ModAction = "delete"
+	ModWarn   ModAction = "warn"
+	ModMute   ModAction = "mute"
+	ModKick   ModAction = "kick"
+)
+
+type ModRule struct {
+	On     bool      `json:"on"`
+	Action ModAction `json:"action"`
+}
+
+// GroupModeration is the per-group configuration
+type GroupModeration struct {
+	Enabled bool `json:"enabled"`
+
+	Rules map[string]*ModRule `json:"rules"` // see modRuleNames
+
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	SpamRepeats    int      `json:"spam_repeats"`  // same text this many times...
+	SpamWindow     int      `json:"spam_window"`   // ...within seconds
+	FloodCount     int      `json:"flood_count"`   // messages...
+	FloodWindow    int      `json:"flood_window"`  // ...within seconds
+	MaxMentions    int      `json:"max_mentions"`  // mentions in one message
+	ForwardScore   int      `json:"forward_score"` // 5 = "forwarded many times"
+	MuteMinutes    int      `json:"mute_minutes"`
+}
+
+// Rule names in display order, with what they catch
+var modRuleNames = []string{"invite", "link", "spam", "flood", "mention", "forward"}
+
+var modRuleInfo = map[string]string{
+	"invite":  "WhatsApp group/channel invite links",
+	"link":    "any other link",
+	"spam":    "the same message repeated",
+	"flood":   "too many messages in a burst",
+	"mention": "mass mentions",
+	"forward": "forwarded many times",
+}
+
+func defaultGroupModeration() *GroupModeration {
+	return &GroupModeration{
+		Rules: map[string]*ModRule{
+			"invite":  {On: true, Action: ModDelete},
+			"link":    {On: false, Action: ModDelete},
+			"spam":    {On: true, Action: ModWarn},
+			"flood":   {On: true, Action: ModMute},
+			"mention": {On: true, Action: ModDelete},
+			"forward": {On: false, Action: ModDelete},
+		},
+		SpamRepeats: 3, SpamWindow: 60,
+		FloodCount: 7, FloodWindow: 10,
+		MaxMentions: 5, ForwardScore: 5,
+		MuteMinutes: 5,
+	}
+}
+
+// BotModeration: تمام گروپس کی سیٹنگز ایک بوٹ کے لیے
+type BotModeration struct {
+	Groups map[string]*GroupModeration `json:"groups,omitempty"`
+}
+
+const bucketModeration = "moderation"
+
+var (
+	modCache = make(map[string]*BotModeration)
+	modMutex sync.RWMutex
+)
+
+// loadModeration mirrors loadPermissions: cached, loaded on first use, failures not cached
+func loadModeration(botID string) (*BotModeration, error) {
+	modMutex.RLock()
+	m, ok := modCache[botID]
+	modMutex.RUnlock()
+	if ok {
+		return m, nil
+	}
+
+	m = &BotModeration{}
+	if _, err := getJSON(bucketModeration, botID, m); err != nil {
+		fmt.Printf("⚠️ Moderation Load Failed (%s): %v\n", botID, err)
+		return nil, err
+	}
+	modMutex.Lock()
+	if cached, ok := modCache[botID]; ok {
+		m = cached
+	} else {
+		modCache[botID] = m
+	}
+	modMutex.Unlock()
+	return m, nil
+}
+
+// updateGroupModeration applies fn to chat's config (created with defaults) and persists
+func updateGroupModeration(botID, chat string, fn func(g *GroupModeration)) error {
+	m, err := loadModeration(botID)
+	if err != nil {
+		return err // saving now would overwrite every group's config
+	}
+	modMutex.Lock()
+	if m.Groups == nil {
+		m.Groups = make(map[string]*GroupModeration)
+	}
+	if m.Groups[chat] == nil {
+		m.Groups[chat] = defaultGroupModeration()
+	}
+	fn(m.Groups[chat])
+	raw, err := json.Marshal(m)
+	modMutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return putJSON(bucketModeration, botID, json.RawMessage(raw))
+}
+
+// groupModeration returns a copy of chat's config, nil when moderation is off or unreadable
+func groupModeration(botID, chat string) *GroupModeration {
+	m, err := loadModeration(botID)
+	if err != nil {
+		return nil
+	}
+	modMutex.RLock()
+	defer modMutex.RUnlock()
+	g := m.Groups[chat]
+	if g == nil || !g.Enabled {
+		return nil
+	}
+	c := *g
+	c.Rules = make(map[string]*ModRule, len(g.Rules))
+	for name, r := range g.Rules {
+		rc := *r
+		c.Rules[name] = &rc
+	}
+	c.AllowedDomains = append([]string(nil), g.AllowedDomains...)
+	return &c
+}
+
+func deleteModeration(botID string) {
+	modMutex.Lock()
+	delete(modCache, botID)
+	modMutex.Unlock()
+	dataStore.Delete(context.Background(), bucketModeration, botID)
+}
+
+// ==========================================
+// 🔍 DETECTION
+// ==========================================
+
+var (
+	inviteLinkRe = regexp.MustCompile(`(?i)(chat\.whatsapp\.com/[a-z0-9]+|whatsapp\.com/channel/[a-z0-9]+)`)
+	urlRe        = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s]+|\b[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|me|ly|gg|xyz|link|info|co|app|dev|pk|in|ru|tk|site|online|shop|live)\b(?:/[^\s]*)?`)
+)
+
+// senderActivity is the short-term memory used by spam/flood checks
+type senderActivity struct {
+	Times    []time.Time
+	LastText string
+	Repeats  []time.Time
+}
+
+var (
+	activity      = make(map[string]*senderActivity) // bot|chat|sender
+	activityMutex sync.Mutex
+)
+
+// messageContextInfo finds the ContextInfo of the common message types
+func messageContextInfo(m *waProto.Message) *waProto.ContextInfo {
+	switch {
+	case m.GetExtendedTextMessage() != nil:
+		return m.GetExtendedTextMessage().GetContextInfo()
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetContextInfo()
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetContextInfo()
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetContextInfo()
+	case m.GetAudioMessage() != nil:
+		return m.GetAudioMessage().GetContextInfo()
+	case m.GetStickerMessage() != nil:
+		return m.GetStickerMessage().GetContextInfo()
+	}
+	return nil
+}
+
+// findViolation returns the first rule the message breaks and a short reason
+func findViolation(g *GroupModeration, key string, v *events.Message) (string, string) {
+	text := getText(v.Message)
+	ctxInfo := messageContextInfo(v.Message)
+	on := func(rule string) bool { return g.Rules[rule] != nil && g.Rules[rule].On }
+
+	if on("invite") && inviteLinkRe.MatchString(text) {
+		return "invite", "invite links are not allowed"
+	}
+	if on("link") {
+		for _, u := range urlRe.FindAllString(text, -1) {
+			if !domainAllowed(u, g.AllowedDomains) {
+				return "link", "links are not allowed"
+			}
+		}
+	}
+	if on("mention") && g.MaxMentions > 0 && len(ctxInfo.GetMentionedJID()) >= g.MaxMentions {
+		return "mention", "mass mentions are not allowed"
+	}
+	if on("forward") && ctxInfo.GetIsForwarded() && int(ctxInfo.GetForwardingScore()) >= g.ForwardScore {
+		return "forward", "frequently forwarded messages are not allowed"
+	}
+
+	// Spam & flood need the sender's recent history
+	now := time.Now()
+	activityMutex.Lock()
+	defer activityMutex.Unlock()
+	a := activity[key]
+	if a == nil {
+		a = &senderActivity{}
+		activity[key] = a
+	}
+
+	a.Times = append(pruneTimes(a.Times, now.Add(-time.Duration(g.FloodWindow)*time.Second)), now)
+	if on("flood") && g.FloodCount > 0 && len(a.Times) >= g.FloodCount {
+		a.Times = nil
+		return "flood", "slow down"
+	}
+
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if normalized == "" {
+		return "", ""
+	}
+	if normalized != a.LastText {
+		a.LastText, a.Repeats = normalized, nil
+	}
+	a.Repeats = append(pruneTimes(a.Repeats, now.Add(-time.Duration(g.SpamWindow)*time.Second)), now)
+	if on("spam") && g.SpamRepeats > 1 && len(a.Repeats) >= g.SpamRepeats {
+		a.Repeats = nil
+		return "spam", "don't repeat the same message"
+	}
+	return "", ""
+}
+
+func pruneTimes(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+func domainAllowed(link string, allowed []string) bool {
+	host := strings.ToLower(link)
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	host = strings.TrimPrefix(host, "www.")
+	if i := strings.IndexAny(host, "/?#:"); i >= 0 {
+		host = host[:i]
+	}
+	for _, d := range allowed {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// ==========================================
+// ⚔️ ENFORCEMENT
+// ==========================================
+
+// moderateMessage returns true when the message broke a rule and was handled
+func moderateMessage(client *whatsmeow.Client, v *events.Message) bool {
+	if !v.Info.IsGroup || v.Info.IsFromMe {
+		return false
+	}
+	botID := getCleanID(client.Store.ID.User)
+	chat := v.Info.Chat.String()
+	g := groupModeration(botID, chat)
+	if g == nil {
+		return false
+	}
+
+	key := botID + "|" + chat + "|" + senderIdentity(client, v)
+	rule, reason := findViolation(g, key, v)
+	if rule == "" {
+		return false
+	}
+	// Exempt: group admins, moderators, sudo and owners
+	if roleOf(client, v) >= RoleModerator {
+		return false
+	}
+
+	action := g.Rules[rule].Action
+	fmt.Printf("🚨 [MOD] Bot:%s | Group:%s | %s -> %s (%s)\n", botID, chat, v.Info.Sender.User, action, rule)
+	applyModAction(client, v, g, action, reason)
+	return true
+}
+
+func applyModAction(client *whatsmeow.Client, v *events.Message, g *GroupModeration, action ModAction, reason string) {
+	ctx := context.Background()
+	chat, sender := v.Info.Chat, v.Info.Sender.ToNonAD()
+
+	// Every action removes the offending message first.
+	// RevokeMessage only covers our own messages; admins revoke others via BuildRevoke.
+	if _, err := client.SendMessage(ctx, chat, client.BuildRevoke(chat, sender, v.Info.ID)); err != nil {
+		fmt.Printf("⚠️ [MOD] Revoke failed: %v\n", err)
+	}
+
+	switch action {
+	case ModWarn:
+		issueWarning(client, chat, sender, "auto", reason) // see warnings.go
+
+	case ModMute:
+		if muteGroup(client, chat, time.Duration(g.MuteMinutes)*time.Minute) {
+			sendMention(client, chat, fmt.Sprintf("🔇 @%s, %s. Group muted for %d min.", sender.User, reason, g.MuteMinutes), []types.JID{sender})
+		}
+
+	case ModKick:
+		if _, err := client.UpdateGroupParticipants(ctx, chat, []types.JID{sender}, whatsmeow.ParticipantChangeRemove); err != nil {
+			fmt.Printf("⚠️ [MOD] Kick failed: %v\n", err)
+			return
+		}
+		sendMention(client, chat, fmt.Sprintf("👢 @%s removed: %s.", sender.User, reason), []types.JID{sender})
+	}
+}
+
+// ==========================================
+// 🔇 AUTO-MUTE (persisted, per bot lifecycle)
+// ==========================================
+
+// Reopen times live in bucketMutes (botID -> chat -> until) so a restart
+// still reopens the group; the timers belong to the bot lifecycle and stop
+// with it, so a removed or re-paired bot never acts on a dead client.
+
+const (
+	bucketMutes     = "mutes"
+	MuteRetryDelay  = time.Minute
+	MuteRetryWindow = time.Hour // e.g. the bot lost admin; give up after this
+)
+
+// groupMutes are the pending reopens of one bot lifecycle
+type groupMutes struct {
+	ctx    context.Context
+	client *whatsmeow.Client
+	botID  string
+	until  map[string]time.Time // chat -> reopen time (persisted)
+	timers map[string]*time.Timer
+}
+
+var (
+	mutes     = make(map[string]*groupMutes) // botID
+	muteMutex sync.Mutex
+)
+
+// resumeMutes loads botID's pending mutes and schedules their reopen until ctx ends
+func resumeMutes(ctx context.Context, client *whatsmeow.Client, botID string) {
+	m := &groupMutes{ctx: ctx, client: client, botID: botID, until: make(map[string]time.Time), timers: make(map[string]*time.Timer)}
+	if _, err := getJSON(bucketMutes, botID, &m.until); err != nil {
+		fmt.Printf("⚠️ [MOD] Mutes Load Failed (%s): %v\n", botID, err)
+	}
+
+	muteMutex.Lock()
+	mutes[botID] = m
+	for chat, until := range m.until {
+		m.scheduleLocked(chat, time.Until(until))
+	}
+	muteMutex.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		muteMutex.Lock()
+		defer muteMutex.Unlock()
+		for _, t := range m.timers {
+			t.Stop()
+		}
+		if mutes[botID] == m {
+			delete(mutes, botID)
+		}
+	})
+}
+
+// muteGroup switches the group to admins-only and reopens it after d (repeats extend the mute).
+// A group that admins had already closed is left alone, so it is never reopened by us.
+func muteGroup(client *whatsmeow.Client, chat types.JID, d time.Duration) bool {
+	if d <= 0 {
+		d = 5 * time.Minute
+	}
+	botID := getCleanID(client.Store.ID.User)
+	key := chat.String()
+
+	muteMutex.Lock()
+	defer muteMutex.Unlock()
+	m := mutes[botID]
+	if m == nil || m.client != client {
+		fmt.Printf("⚠️ [MOD] Mute skipped: %s is not running\n", botID)
+		return false
+	}
+	if _, muted := m.until[key]; !muted {
+		info, err := client.GetGroupInfo(m.ctx, chat)
+		if err != nil {
+			fmt.Printf("⚠️ [MOD] Mute failed: %v\n", err)
+			return false
+		}
+		if info.IsAnnounce {
+			return false
+		}
+		if err := client.SetGroupAnnounce(m.ctx, chat, true); err != nil {
+			fmt.Printf("⚠️ [MOD] Mute failed: %v\n", err)
+			return false
+		}
+	}
+	m.until[key] = time.Now().Add(d)
+	m.saveLocked()
+	m.scheduleLocked(key, d)
+	return true
+}
+
+// scheduleLocked (re)arms the reopen of chat. Caller must hold muteMutex.
+func (m *groupMutes) scheduleLocked(chat string, d time.Duration) {
+	if t := m.timers[chat]; t != nil {
+		t.Reset(d)
+		return
+	}
+	m.timers[chat] = time.AfterFunc(d, func() { m.reopen(chat) })
+}
+
+// reopen lifts the mute, retrying every MuteRetryDelay for up to MuteRetryWindow
+func (m *groupMutes) reopen(chat string) {
+	jid, _ := types.ParseJID(chat)
+	err := m.client.SetGroupAnnounce(m.ctx, jid, false)
+
+	muteMutex.Lock()
+	defer muteMutex.Unlock()
+	if m.ctx.Err() != nil {
+		return // lifecycle over, the next one resumes from the store
+	}
+	if err != nil {
+		fmt.Printf("⚠️ [MOD] Reopen failed (%s): %v\n", chat, err)
+		if time.Since(m.until[chat]) < MuteRetryWindow {
+			m.timers[chat].Reset(MuteRetryDelay)
+			return
+		}
+	}
+	delete(m.timers, chat)
+	delete(m.until, chat)
+	m.saveLocked()
+	if err == nil {
+		m.client.SendMessage(m.ctx, jid, &waProto.Message{Conversation: proto.String("🔊 Group reopened.")})
+	}
+}
+
+// saveLocked persists the pending mutes. Caller must hold muteMutex.
+func (m *groupMutes) saveLocked() {
+	var err error
+	if len(m.until) == 0 {
+		err = dataStore.Delete(context.Background(), bucketMutes, m.botID)
+	} else {
+		err = putJSON(bucketMutes, m.botID, m.until)
+	}
+	if err != nil {
+		fmt.Printf("⚠️ [MOD] Mutes Save Failed (%s): %v\n", m.botID, err)
+	}
+}
+
+// sendMention posts text that mentions jids
+func sendMention(client *whatsmeow.Client, chat types.JID, text string, jids []types.JID) {
+	mentions := make([]string, len(jids))
+	for i, j := range jids {
+		mentions[i] = j.String()
+	}
+	client.SendMessage(context.Background(), chat, &waProto.Message{
+		ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(text),
+			ContextInfo: &waProto.ContextInfo{MentionedJID: mentions},
+		},
+	})
+}
+
+// moderationSweepLoop forgets idle senders so the activity map stays small
+func moderationSweepLoop() {
+	for range time.Tick(5 * time.Minute) {
+		cutoff := time.Now().Add(-10 * time.Minute)
+		activityMutex.Lock()
+		for key, a := range activity {
+			if len(a.Times) == 0 || a.Times[len(a.Times)-1].Before(cutoff) {
+				delete(activity, key)
+			}
+		}
+		activityMutex.Unlock()
+	}
+}
+
+// ==========================================
+// 🛠️ .mod COMMAND
+// ==========================================
+
+// HandleModeration: .mod [on|off | <rule> on|off [action] | set <key> <n> | allow|disallow <domain>]
+func HandleModeration(c *CmdContext) {
+	chat := c.Msg.Info.Chat.String()
+	action, value, extra := strings.ToLower(c.Str("action")), strings.ToLower(c.Str("value")), strings.ToLower(c.Str("extra"))
+	update := func(fn func(g *GroupModeration)) bool {
+		if err := updateGroupModeration(c.BotID, chat, fn); err != nil {
+			c.Reply("❌ Save failed: " + err.Error())
+			return false
+		}
+		return true
+	}
+
+	switch {
+	case action == "" || action == "status":
+		c.Reply(describeModeration(c.BotID, chat, c.Prefix))
+
+	case action == "on" || action == "off":
+		if update(func(g *GroupModeration) { g.Enabled = action == "on" }) {
+			c.Reply("🚨 Moderation: " + strings.ToUpper(action))
+		}
+
+	case modRuleInfo[action] != "":
+		if value != "on" && value != "off" {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %smod %s on|off [delete|warn|mute|kick]", c.Prefix, action))
+			return
+		}
+		act := ModAction(extra)
+		if extra != "" && act != ModDelete && act != ModWarn && act != ModMute && act != ModKick {
+			c.Reply("❌ Action must be delete, warn, mute or kick")
+			return
+		}
+		ok := update(func(g *GroupModeration) {
+			if g.Rules == nil { g.Rules = defaultGroupModeration().Rules }
+			r := g.Rules[action]
+			if r == nil {
+				r = &ModRule{Action: ModDelete}
+				g.Rules[action] = r
+			}
+			r.On = value == "on"
+			if act != "" { r.Action = act }
+		})
+		if ok {
+			c.Reply(fmt.Sprintf("✅ Anti-%s: %s", action, strings.ToUpper(value)))
+		}
+
+	case action == "set":
+		n, err := strconv.Atoi(extra)
+		fields := map[string]func(g *GroupModeration) *int{
+			"repeats":   func(g *GroupModeration) *int { return &g.SpamRepeats },
+			"spamtime":  func(g *GroupModeration) *int { return &g.SpamWindow },
+			"flood":     func(g *GroupModeration) *int { return &g.FloodCount },
+			"floodtime": func(g *GroupModeration) *int { return &g.FloodWindow },
+			"mentions":  func(g *GroupModeration) *int { return &g.MaxMentions },
+			"forwards":  func(g *GroupModeration) *int { return &g.ForwardScore },
+			"mute":      func(g *GroupModeration) *int { return &g.MuteMinutes },
+		}
+		field := fields[value]
+		if field == nil || err != nil || n < 1 || n > 1000 {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %smod set repeats|spamtime|flood|floodtime|mentions|forwards|mute <1-1000>", c.Prefix))
+			return
+		}
+		if update(func(g *GroupModeration) { *field(g) = n }) {
+			c.Reply(fmt.Sprintf("✅ %s = %d", value, n))
+		}
+
+	case action == "allow" || action == "disallow":
+		domain := strings.TrimPrefix(value, "www.")
+		if domain == "" || !strings.Contains(domain, ".") {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %smod %s example.com", c.Prefix, action))
+			return
+		}
+		ok := update(func(g *GroupModeration) {
+			g.AllowedDomains = withoutString(g.AllowedDomains, domain)
+			if action == "allow" { g.AllowedDomains = append(g.AllowedDomains, domain) }
+		})
+		if ok {
+			c.Reply(fmt.Sprintf("✅ %s: %s", action, domain))
+		}
+
+	default:
+		c.Reply(fmt.Sprintf("❌ Unknown option. See *%smod*", c.Prefix))
+	}
+}
+
+func describeModeration(botID, chat, prefix string) string {
+	m, err := loadModeration(botID)
+	if err != nil {
+		return "❌ Failed to load moderation settings."
+	}
+	modMutex.RLock()
+	g := m.Groups[chat]
+	var sb strings.Builder
+	if g == nil {
+		g = defaultGroupModeration()
+	}
+	status := "OFF"
+	if g.Enabled { status = "ON" }
+	sb.WriteString(fmt.Sprintf("🚨 *Moderation: %s*\n\n", status))
+	for _, name := range modRuleNames {
+		r := g.Rules[name]
+		if r == nil { r = &ModRule{Action: ModDelete} }
+		icon := "⚪"
+		if r.On { icon = "🟢" }
+		sb.WriteString(fmt.Sprintf("%s *%s* → %s\n    _%s_\n", icon, name, r.Action, modRuleInfo[name]))
+	}
+	domains := append([]string(nil), g.AllowedDomains...)
+	sb.WriteString(fmt.Sprintf("\n🔁 Spam: %d repeats / %ds\n🌊 Flood: %d msgs / %ds\n📣 Mentions: %d\n↪️ Forward score: %d\n🔇 Mute: %d min\n",
+		g.SpamRepeats, g.SpamWindow, g.FloodCount, g.FloodWindow, g.MaxMentions, g.ForwardScore, g.MuteMinutes))
+	modMutex.RUnlock()
+
+	sort.Strings(domains)
+	if len(domains) > 0 {
+		sb.WriteString("✅ Allowed: " + strings.Join(domains, ", ") + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("\n%smod on|off\n%smod <rule> on|off [delete|warn|mute|kick]\n%smod set <key> <n>\n%smod allow|disallow <domain>",
+		prefix, prefix, prefix, prefix))
+	return sb.String()
+}
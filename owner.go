@@ -4,6 +4,7 @@ import (
 	"context" // ✅ Fix
 	"fmt"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,16 +31,52 @@ func HandleSetPrefix(client *whatsmeow.Client, v *events.Message, args []string)
 	ReplyMessage(client, v, "✅ Prefix Set!")
 }
 
+// Bot modes: public = everyone, admin = moderators+ (group admins count), private = sudo+
+var botModes = []string{"public", "admin", "private"}
+
+// botMode is the mode in effect in chat: the group's override, else the bot-wide mode
+func botMode(botID, chat string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	s := sm.Settings[botID]
+	if s == nil { return "public" }
+	if m := s.GroupModes[chat]; m != "" { return m }
+	if s.Mode == "" { return "public" }
+	return s.Mode
+}
+
+// HandleMode: .mode public|admin|private [here]   .mode reset (drops this group's override)
 func HandleMode(client *whatsmeow.Client, v *events.Message, args []string) {
-	if len(args) == 0 { ReplyMessage(client, v, "⚠️ .mode public|private"); return }
+	usage := "⚠️ .mode public|admin|private [here] | .mode reset"
+	if len(args) == 0 { ReplyMessage(client, v, usage); return }
 	mode := strings.ToLower(args[0])
+	here := len(args) > 1 && strings.EqualFold(args[1], "here")
+	if mode != "reset" && !slices.Contains(botModes, mode) { ReplyMessage(client, v, usage); return }
+	if (here || mode == "reset") && !v.Info.IsGroup {
+		ReplyMessage(client, v, "❌ Group overrides only work inside a group.")
+		return
+	}
+
 	botID := getCleanID(client.Store.ID.User)
+	chat := v.Info.Chat.String()
 	sm.mu.Lock()
-	if sm.Settings[botID] == nil { sm.Settings[botID] = &BotSettings{} }
-	sm.Settings[botID].Mode = mode
+	if sm.Settings[botID] == nil { sm.Settings[botID] = &BotSettings{Prefix: ".", AlwaysOnline: true, Mode: "public"} }
+	s := sm.Settings[botID]
+	reply := "✅ Mode Set: " + mode
+	switch {
+	case mode == "reset":
+		delete(s.GroupModes, chat)
+		reply = "♻️ This group follows the bot mode again: " + s.Mode
+	case here:
+		if s.GroupModes == nil { s.GroupModes = make(map[string]string) }
+		s.GroupModes[chat] = mode
+		reply += " (this group)"
+	default:
+		s.Mode = mode
+	}
 	sm.mu.Unlock()
 	saveSettings()
-	ReplyMessage(client, v, "✅ Mode Set: "+mode)
+	ReplyMessage(client, v, reply)
 }
 
 // ==========================================
@@ -69,6 +106,8 @@ func HandleToggle(client *whatsmeow.Client, v *events.Message, command string) {
 		settings.AutoStatus = !settings.AutoStatus; if settings.AutoStatus { status = "ON" }
 	case "statusreact":
 		settings.StatusReact = !settings.StatusReact; if settings.StatusReact { status = "ON" }
+	case "welcomeall":
+		settings.WelcomeMsg = !settings.WelcomeMsg; if settings.WelcomeMsg { status = "ON" }
 	}
 	sm.mu.Unlock()
 	saveSettings()
@@ -79,38 +118,133 @@ func HandleToggle(client *whatsmeow.Client, v *events.Message, command string) {
 // 📊 STATS
 // ==========================================
 
+// HandleStats counts only bots of the same tenant as this bot
 func HandleStats(client *whatsmeow.Client, v *events.Message) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 	uptime := time.Since(StartTime).Round(time.Second)
-	sm.mu.RLock()
-	active := len(sm.Clients)
-	sm.mu.RUnlock()
+	active := len(tenantBots(botTenant(getCleanID(client.Store.ID.User))))
 	msg := fmt.Sprintf("📊 *SYSTEM STATS*\n\n⏳ Uptime: %s\n🤖 Active Bots: %d\n💾 RAM: %v MB", uptime, active, m.Alloc/1024/1024)
 	ReplyMessage(client, v, msg)
 }
 
+// HandleListBots only shows bots of the same tenant as this bot
 func HandleListBots(client *whatsmeow.Client, v *events.Message) {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
+	tenant := botTenant(getCleanID(client.Store.ID.User))
 	msg := "🤖 *ACTIVE SESSIONS*\n"
-	for id := range sm.Clients { msg += "• " + id + "\n" }
+	for _, id := range tenantBots(tenant) { msg += "• " + id + "\n" }
 	ReplyMessage(client, v, msg)
 }
 
 func HandleDeleteSession(client *whatsmeow.Client, v *events.Message, args []string) {
-	if len(args) == 0 { ReplyMessage(client, v, "⚠️ Provide number"); return }
-	target := strings.ReplaceAll(args[0], "+", "")
+	if len(args) == 0 { ReplyMessage(client, v, "⚠️ Usage: .sd <number> [logout]"); return }
+	target := getCleanID(strings.ReplaceAll(args[0], "+", ""))
+	logout := len(args) > 1 && strings.EqualFold(args[1], "logout")
+
+	// Owners can only delete sessions of their own tenant
+	if botTenant(target) != botTenant(getCleanID(client.Store.ID.User)) {
+		ReplyMessage(client, v, "❌ No session found for "+target)
+		return
+	}
+
+	found, err := sm.Remove(target, RemoveOptions{Logout: logout})
+	if !found {
+		ReplyMessage(client, v, "❌ No session found for "+target)
+		return
+	}
+	if err != nil {
+		ReplyMessage(client, v, "⚠️ Session removed, but cleanup had errors: "+err.Error())
+		return
+	}
+	ReplyMessage(client, v, "🗑️ Session Deleted: "+target)
+}
+
+// ==========================================
+// 👥 OWNERS LIST
+// ==========================================
+
+// normalizeOwner keeps digits only ("+92 300-1234567" -> "923001234567")
+func normalizeOwner(raw string) (string, bool) {
+	id := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' { return r }
+		return -1
+	}, getCleanID(raw))
+	return id, len(id) >= 7
+}
+
+func botOwners(botID string) []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if s := sm.Settings[botID]; s != nil {
+		return append([]string(nil), s.Owners...)
+	}
+	return nil
+}
+
+func isListedOwner(botID, id string) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if s := sm.Settings[botID]; s != nil {
+		for _, o := range s.Owners {
+			if o == id { return true }
+		}
+	}
+	return false
+}
+
+// addBotOwner returns false if id was already an owner
+func addBotOwner(botID, id string) bool {
 	sm.mu.Lock()
-	if c, ok := sm.Clients[target]; ok {
-		c.Disconnect()
-		delete(sm.Clients, target)
-		delete(sm.Settings, target)
+	if sm.Settings[botID] == nil { sm.Settings[botID] = &BotSettings{Prefix: ".", AlwaysOnline: true, Mode: "public"} }
+	s := sm.Settings[botID]
+	for _, o := range s.Owners {
+		if o == id { sm.mu.Unlock(); return false }
 	}
+	s.Owners = append(s.Owners, id)
 	sm.mu.Unlock()
-	
-	// DB delete logic requires iterating devices in main.go, 
-	// for simplicity we just remove from RAM and Save settings here.
 	saveSettings()
-	ReplyMessage(client, v, "🗑️ Session Deleted (RAM Only)")
+	return true
+}
+
+// removeBotOwner returns false if id was not listed
+func removeBotOwner(botID, id string) bool {
+	sm.mu.Lock()
+	s := sm.Settings[botID]
+	if s == nil { sm.mu.Unlock(); return false }
+	for i, o := range s.Owners {
+		if o == id {
+			s.Owners = append(s.Owners[:i], s.Owners[i+1:]...)
+			sm.mu.Unlock()
+			saveSettings()
+			return true
+		}
+	}
+	sm.mu.Unlock()
+	return false
+}
+
+func HandleAddOwner(client *whatsmeow.Client, v *events.Message, args []string) {
+	target, found := GetTarget(v, args)
+	if !found { ReplyMessage(client, v, "⚠️ Usage: .addowner @user | number"); return }
+	botID := getCleanID(client.Store.ID.User)
+	id := resolveIdentity(client, target)
+	if id == botID { ReplyMessage(client, v, "ℹ️ The bot number is always an owner."); return }
+	if !addBotOwner(botID, id) { ReplyMessage(client, v, "ℹ️ Already an owner: "+id); return }
+	ReplyMessage(client, v, "👑 Owner added: "+id)
+}
+
+func HandleDelOwner(client *whatsmeow.Client, v *events.Message, args []string) {
+	target, found := GetTarget(v, args)
+	if !found { ReplyMessage(client, v, "⚠️ Usage: .delowner @user | number"); return }
+	botID := getCleanID(client.Store.ID.User)
+	id := resolveIdentity(client, target)
+	if !removeBotOwner(botID, id) { ReplyMessage(client, v, "❌ Not an owner: "+id); return }
+	ReplyMessage(client, v, "🗑️ Owner removed: "+id)
+}
+
+func HandleListOwners(client *whatsmeow.Client, v *events.Message) {
+	botID := getCleanID(client.Store.ID.User)
+	msg := "👑 *OWNERS*\n• " + botID + " (bot)\n"
+	for _, o := range botOwners(botID) { msg += "• " + o + "\n" }
+	ReplyMessage(client, v, msg)
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// ==========================================
+// 🖥️ PAIRING CLIENT IDENTITY
+// ==========================================
+
+// Allowed client types for PairPhone
+var pairClientTypes = map[string]whatsmeow.PairClientType{
+	"chrome":   whatsmeow.PairClientChrome,
+	"firefox":  whatsmeow.PairClientFirefox,
+	"edge":     whatsmeow.PairClientEdge,
+	"safari":   whatsmeow.PairClientSafari,
+	"opera":    whatsmeow.PairClientOpera,
+	"ie":       whatsmeow.PairClientIE,
+	"electron": whatsmeow.PairClientElectron,
+	"uwp":      whatsmeow.PairClientUWP,
+	"other":    whatsmeow.PairClientOtherWebClient,
+}
+
+// Default display name per client type
+var pairClientNames = map[string]string{
+	"chrome":   "Chrome (Linux)",
+	"firefox":  "Firefox (Linux)",
+	"edge":     "Edge (Windows)",
+	"safari":   "Safari (Mac OS)",
+	"opera":    "Opera (Windows)",
+	"ie":       "IE (Windows)",
+	"electron": "Electron (Linux)",
+	"uwp":      "WhatsApp (Windows)",
+	"other":    "Web (Linux)",
+}
+
+// Tried in this order after the requested client fails
+var pairFallbackOrder = []string{"chrome", "firefox", "edge", "safari"}
+
+const MaxDisplayNameLen = 64
+
+// WhatsApp expects "Browser (OS)"
+var displayNameRe = regexp.MustCompile(`^[\p{L}\p{N} ._-]+ \([\p{L}\p{N} ._-]+\)$`)
+
+type PairClient struct {
+	Type        string `json:"client_type"`
+	DisplayName string `json:"display_name"`
+}
+
+// PairAttempt: ایک client variant کی کوشش کا نتیجہ
+type PairAttempt struct {
+	PairClient
+	Error string `json:"error,omitempty"`
+}
+
+// resolvePairClients validates the request and returns the variants to try, in order
+func resolvePairClients(clientType, displayName string) ([]PairClient, error) {
+	clientType = strings.ToLower(strings.TrimSpace(clientType))
+	displayName = strings.TrimSpace(displayName)
+
+	if clientType == "" {
+		clientType = "chrome"
+	}
+	if _, ok := pairClientTypes[clientType]; !ok {
+		return nil, fmt.Errorf("unsupported client_type %q", clientType)
+	}
+	if displayName != "" {
+		if len(displayName) > MaxDisplayNameLen || !displayNameRe.MatchString(displayName) {
+			return nil, fmt.Errorf("display_name must look like \"Browser (OS)\"")
+		}
+	} else {
+		displayName = pairClientNames[clientType]
+	}
+
+	variants := []PairClient{{Type: clientType, DisplayName: displayName}}
+	for _, t := range pairFallbackOrder {
+		if t != clientType {
+			variants = append(variants, PairClient{Type: t, DisplayName: pairClientNames[t]})
+		}
+	}
+	return variants, nil
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// ==========================================
+// 🔗 PAIRING SESSIONS (State Machine)
+// ==========================================
+
+type PairState string
+
+const (
+	PairConnecting PairState = "connecting"
+	PairCodeIssued PairState = "code_issued"
+	PairPaired     PairState = "paired"
+	PairTimedOut   PairState = "timed_out"
+	PairFailed     PairState = "failed"
+	PairCancelled  PairState = "cancelled"
+)
+
+// Pairing modes
+const (
+	PairModeCode = "code"
+	PairModeQR   = "qr"
+)
+
+const (
+	PairTimeout      = 120 * time.Second
+	PairCodeWait     = 30 * time.Second
+	PairSessionKeep  = 10 * time.Minute
+	PairCleanupEvery = 1 * time.Minute
+)
+
+var errPairCancelled = errors.New("pairing cancelled")
+
+func (s PairState) Terminal() bool {
+	switch s {
+	case PairPaired, PairTimedOut, PairFailed, PairCancelled:
+		return true
+	}
+	return false
+}
+
+// PairStatus is what the API and /ws see of a pairing attempt
+type PairStatus struct {
+	ID        string        `json:"id"`
+	Mode      string        `json:"mode"`
+	Number    string        `json:"number,omitempty"`
+	State     PairState     `json:"state"`
+	Code      string        `json:"code,omitempty"`
+	QR        string        `json:"qr,omitempty"`
+	Client    *PairClient   `json:"client,omitempty"`
+	Attempts  []PairAttempt `json:"attempts,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// PairSession: ہر pairing attempt کا اپنا ID اور سٹیٹ
+type PairSession struct {
+	PairStatus // guarded by mu
+
+	tenant   string // owner of the bot once paired
+	variants []PairClient
+	client   *whatsmeow.Client
+	device   *store.Device
+	cancel   context.CancelCauseFunc
+	// ready: code issued or session ended. done: session ended.
+	ready     chan struct{}
+	done      chan struct{}
+	readyOnce sync.Once
+	mu        sync.Mutex
+}
+
+type PairManager struct {
+	sessions map[string]*PairSession
+	mu       sync.RWMutex
+}
+
+var pairs = &PairManager{sessions: make(map[string]*PairSession)}
+
+func init() {
+	go pairs.cleanupLoop()
+}
+
+func (pm *PairManager) Get(id string) *PairSession {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.sessions[id]
+}
+
+func (pm *PairManager) add(ps *PairSession) {
+	pm.mu.Lock()
+	pm.sessions[ps.ID] = ps
+	pm.mu.Unlock()
+}
+
+// cleanupLoop forgets finished sessions after PairSessionKeep
+func (pm *PairManager) cleanupLoop() {
+	for range time.Tick(PairCleanupEvery) {
+		pm.mu.Lock()
+		for id, ps := range pm.sessions {
+			snap := ps.Snapshot()
+			if snap.State.Terminal() && time.Since(snap.UpdatedAt) > PairSessionKeep {
+				delete(pm.sessions, id)
+			}
+		}
+		pm.mu.Unlock()
+	}
+}
+
+// Snapshot returns a copy that is safe to encode
+func (ps *PairSession) Snapshot() PairStatus {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	snap := ps.PairStatus
+	snap.Attempts = append([]PairAttempt(nil), ps.Attempts...)
+	return snap
+}
+
+// setState moves the session forward and pushes it over /ws.
+// Terminal states are final; later transitions are ignored.
+func (ps *PairSession) setState(state PairState, errMsg string) bool {
+	ps.mu.Lock()
+	if ps.State.Terminal() {
+		ps.mu.Unlock()
+		return false
+	}
+	ps.State = state
+	ps.Error = errMsg
+	ps.UpdatedAt = time.Now()
+	ps.mu.Unlock()
+
+	fmt.Printf("🔗 [PAIR %s] %s -> %s %s\n", ps.ID, ps.Number, state, errMsg)
+	broadcastWS(WSMessage{Type: "pair_status", BotID: ps.Number, Tenant: ps.tenant, Payload: ps.Snapshot()})
+
+	if state == PairCodeIssued || state.Terminal() {
+		ps.readyOnce.Do(func() { close(ps.ready) })
+	}
+	if state.Terminal() {
+		close(ps.done)
+	}
+	return true
+}
+
+func (ps *PairSession) Cancel() bool {
+	if ps.Snapshot().State.Terminal() {
+		return false
+	}
+	ps.cancel(errPairCancelled)
+	return true
+}
+
+// ==========================================
+// ⚙️ PAIRING FLOW
+// ==========================================
+
+// startPairing: number and variants are used in code mode; QR mode learns the number on success
+func startPairing(tenant, mode, number string, variants []PairClient) *PairSession {
+	now := time.Now()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	ps := &PairSession{
+		PairStatus: PairStatus{
+			ID:        newPairID(),
+			Mode:      mode,
+			Number:    number,
+			State:     PairConnecting,
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(PairTimeout),
+		},
+		tenant:   tenant,
+		variants: variants,
+		cancel:   cancel,
+		ready:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	pairs.add(ps)
+	broadcastWS(WSMessage{Type: "pair_status", BotID: number, Tenant: tenant, Payload: ps.Snapshot()})
+
+	go ps.run(ctx)
+	return ps
+}
+
+func (ps *PairSession) run(ctx context.Context) {
+	ctx, cancelTimeout := context.WithTimeout(ctx, PairTimeout)
+	defer cancelTimeout()
+
+	ps.device = container.NewDevice()
+	ps.client = whatsmeow.NewClient(ps.device, waLog.Stdout("Pairing", "DEBUG", true))
+
+	// QR channel tells us when the socket is ready and when pairing succeeds
+	qrChan, err := ps.client.GetQRChannel(ctx)
+	if err != nil {
+		ps.setState(PairFailed, "QR channel: "+err.Error())
+		return
+	}
+	if err := ps.client.Connect(); err != nil {
+		ps.setState(PairFailed, "WhatsApp Connect Failed: "+err.Error())
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			ps.finishContext(ctx)
+			return
+
+		case item, ok := <-qrChan:
+			if !ok {
+				ps.finishContext(ctx)
+				return
+			}
+			switch item.Event {
+			case whatsmeow.QRChannelEventCode:
+				if ps.Mode == PairModeQR {
+					ps.onQR(item.Code, item.Timeout)
+					continue
+				}
+				// First QR event = socket ready, request the phone code once
+				if ps.Snapshot().State == PairConnecting {
+					if err := ps.requestCode(ctx); err != nil {
+						ps.rollback()
+						ps.setState(PairFailed, "Pairing Failed: "+err.Error())
+						return
+					}
+				}
+			case whatsmeow.QRChannelSuccess.Event:
+				ps.onPaired()
+				return
+			case whatsmeow.QRChannelTimeout.Event:
+				ps.rollback()
+				ps.setState(PairTimedOut, "")
+				return
+			case whatsmeow.QRChannelEventError:
+				ps.rollback()
+				ps.setState(PairFailed, fmt.Sprintf("Pairing Error: %v", item.Error))
+				return
+			default:
+				// err-client-outdated, err-scanned-without-multidevice, ...
+				ps.rollback()
+				ps.setState(PairFailed, item.Event)
+				return
+			}
+		}
+	}
+}
+
+// requestCode tries each client variant until PairPhone succeeds
+func (ps *PairSession) requestCode(ctx context.Context) error {
+	var lastErr error
+	for i, v := range ps.variants {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+		}
+
+		fmt.Printf("📲 [DEBUG] Requesting Pairing Code for: %s (%s / %s)\n", ps.Number, v.Type, v.DisplayName)
+		code, err := ps.client.PairPhone(ctx, ps.Number, true, pairClientTypes[v.Type], v.DisplayName)
+
+		attempt := PairAttempt{PairClient: v}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		ps.mu.Lock()
+		ps.Attempts = append(ps.Attempts, attempt)
+		if err == nil {
+			client := v
+			ps.Client = &client
+			ps.Code = code
+		}
+		ps.mu.Unlock()
+
+		if err == nil {
+			ps.setState(PairCodeIssued, "")
+			return nil
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// onQR: WhatsApp rotates the QR payload every few seconds
+func (ps *PairSession) onQR(code string, timeout time.Duration) {
+	ps.mu.Lock()
+	ps.QR = code
+	first := ps.State == PairConnecting
+	ps.mu.Unlock()
+
+	if first {
+		ps.setState(PairCodeIssued, "")
+	}
+	broadcastWS(WSMessage{Type: "pair_qr", Tenant: ps.tenant, Payload: map[string]interface{}{
+		"id":      ps.ID,
+		"qr":      code,
+		"timeout": int(timeout.Seconds()),
+	}})
+}
+
+func (ps *PairSession) onPaired() {
+	if ps.client.Store.ID == nil {
+		ps.rollback()
+		ps.setState(PairFailed, "Paired without device ID")
+		return
+	}
+	botID := getCleanID(ps.client.Store.ID.User)
+	fmt.Printf("🎉 [DEBUG] SUCCESS! User %s logged in.\n", botID)
+
+	// A number owned by another tenant cannot be taken over (QR mode learns it only now)
+	if ownedByOther(botID, ps.tenant) {
+		ps.rollback()
+		ps.setState(PairFailed, "Number belongs to another account")
+		return
+	}
+	setBotTenant(botID, ps.tenant)
+
+	ps.mu.Lock()
+	ps.Number = botID
+	ps.QR = ""
+	ps.mu.Unlock()
+
+	// Swap the old client/device for the new one
+	adoptPairedClient(ps.client, botID)
+	ps.setState(PairPaired, "")
+	go OnNewPairing(ps.client)
+}
+
+// rollback drops the new device and leaves any existing session untouched
+func (ps *PairSession) rollback() {
+	if ps.client == nil {
+		return
+	}
+	ps.client.Disconnect()
+	if ps.client.Store.ID != nil {
+		ps.device.Delete(context.Background())
+	}
+}
+
+func (ps *PairSession) finishContext(ctx context.Context) {
+	ps.rollback()
+	if errors.Is(context.Cause(ctx), errPairCancelled) {
+		ps.setState(PairCancelled, "")
+		return
+	}
+	ps.setState(PairTimedOut, "")
+}
+
+func newPairID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ==========================================
+// 🌐 PAIRING HTTP HANDLERS
+// ==========================================
+
+// POST /api/pair {"number": "...", "mode": "code|qr"} -> session with code / first QR
+func handlePair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req PairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if mode == "" {
+		mode = PairModeCode
+	}
+	if mode != PairModeCode && mode != PairModeQR {
+		writeError(w, http.StatusBadRequest, "Invalid mode (use code or qr)")
+		return
+	}
+
+	tenant := principalFrom(r).Tenant
+	var ps *PairSession
+	if mode == PairModeQR {
+		ps = startPairing(tenant, PairModeQR, "", nil)
+	} else {
+		variants, err := resolvePairClients(req.ClientType, req.DisplayName)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		number := strings.NewReplacer("+", "", " ", "", "-", "").Replace(req.Number)
+		cleanID := getCleanID(number)
+		if len(number) < 10 {
+			writeError(w, http.StatusBadRequest, "Number too short/invalid format")
+			return
+		}
+
+		if ownedByOther(cleanID, tenant) {
+			writeError(w, http.StatusForbidden, "Number belongs to another account")
+			return
+		}
+
+		// The existing session stays live until the new device is paired
+		ps = startPairing(tenant, PairModeCode, cleanID, variants)
+	}
+
+	// Wait until a code is issued (or the attempt fails early)
+	select {
+	case <-ps.ready:
+	case <-time.After(PairCodeWait):
+	case <-r.Context().Done():
+		return
+	}
+
+	snap := ps.Snapshot()
+	switch snap.State {
+	case PairFailed, PairTimedOut, PairCancelled:
+		writeJSON(w, http.StatusInternalServerError, snap)
+	case PairConnecting:
+		writeJSON(w, http.StatusAccepted, snap)
+	default:
+		writeJSON(w, http.StatusOK, snap)
+	}
+}
+
+// GET /api/pair/{id}
+func handlePairStatus(w http.ResponseWriter, r *http.Request) {
+	ps := pairs.Get(r.PathValue("id"))
+	if ps == nil || !principalFrom(r).CanAccessTenant(ps.tenant) {
+		writeError(w, http.StatusNotFound, "Pairing session not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, ps.Snapshot())
+}
+
+// DELETE /api/pair/{id}
+func handlePairCancel(w http.ResponseWriter, r *http.Request) {
+	ps := pairs.Get(r.PathValue("id"))
+	if ps == nil || !principalFrom(r).CanAccessTenant(ps.tenant) {
+		writeError(w, http.StatusNotFound, "Pairing session not found")
+		return
+	}
+	if !ps.Cancel() {
+		writeJSON(w, http.StatusConflict, ps.Snapshot())
+		return
+	}
+	select {
+	case <-ps.done:
+	case <-time.After(5 * time.Second):
+	}
+	writeJSON(w, http.StatusOK, ps.Snapshot())
+}
+
+// GET /api/pair/{id}/qr.png -> current QR payload as PNG
+func handlePairQR(w http.ResponseWriter, r *http.Request) {
+	ps := pairs.Get(r.PathValue("id"))
+	if ps == nil || !principalFrom(r).CanAccessTenant(ps.tenant) {
+		writeError(w, http.StatusNotFound, "Pairing session not found")
+		return
+	}
+	snap := ps.Snapshot()
+	if snap.Mode != PairModeQR {
+		writeError(w, http.StatusBadRequest, "Not a QR pairing session")
+		return
+	}
+	if snap.QR == "" {
+		writeError(w, http.StatusNotFound, "No QR code available")
+		return
+	}
+
+	png, err := qrcode.Encode(snap.QR, qrcode.Medium, 320)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "QR render failed")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(png)
+}
@@ -0,0 +1,580 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 🎖️ ROLES & PERMISSIONS (per bot, persisted)
+// ==========================================
+
+type Role int
+
+const (
+	RoleBanned Role = iota
+	RoleUser
+	RoleModerator
+	RoleSudo
+	RoleOwner
+)
+
+var roleNames = map[Role]string{
+	RoleBanned:    "banned",
+	RoleUser:      "user",
+	RoleModerator: "moderator",
+	RoleSudo:      "sudo",
+	RoleOwner:     "owner",
+}
+
+func (r Role) String() string { return roleNames[r] }
+
+func (r Role) MarshalJSON() ([]byte, error) { return json.Marshal(r.String()) }
+
+func (r *Role) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	role, ok := parseRole(s)
+	if !ok {
+		return fmt.Errorf("unknown role %q", s)
+	}
+	*r = role
+	return nil
+}
+
+func parseRole(s string) (Role, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "mod" {
+		s = "moderator"
+	}
+	for r, name := range roleNames {
+		if name == s {
+			return r, true
+		}
+	}
+	return RoleUser, false
+}
+
+// BotPermissions: ایک بوٹ کے رولز اور کمانڈ لیولز
+type BotPermissions struct {
+	Users    map[string]Role        `json:"users,omitempty"`    // identity -> role
+	Commands map[string]Role        `json:"commands,omitempty"` // command -> required role
+	Groups   map[string]*GroupPerms `json:"groups,omitempty"`   // group JID -> overrides
+}
+
+// GroupPerms overrides the bot-wide values inside one group
+type GroupPerms struct {
+	Users      map[string]Role `json:"users,omitempty"`
+	Commands   map[string]Role `json:"commands,omitempty"`
+	Restricted bool            `json:"restricted,omitempty"` // only sudo+ may use the bot here
+}
+
+const bucketPermissions = "permissions"
+
+var (
+	permCache = make(map[string]*BotPermissions)
+	permMutex sync.RWMutex
+)
+
+// loadPermissions returns the cached permissions of botID (loaded on first use).
+// Caller must not modify the result without holding permMutex. A failed load is
+// not cached; callers fail closed, since empty permissions would drop every ban.
+func loadPermissions(botID string) (*BotPermissions, error) {
+	permMutex.RLock()
+	p, ok := permCache[botID]
+	permMutex.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	p = &BotPermissions{}
+	if _, err := getJSON(bucketPermissions, botID, p); err != nil {
+		fmt.Printf("⚠️ Permissions Load Failed (%s): %v\n", botID, err)
+		return nil, err
+	}
+	permMutex.Lock()
+	if cached, ok := permCache[botID]; ok {
+		p = cached
+	} else {
+		permCache[botID] = p
+	}
+	permMutex.Unlock()
+	return p, nil
+}
+
+// updatePermissions applies fn under lock and persists the result
+func updatePermissions(botID string, fn func(p *BotPermissions)) error {
+	p, err := loadPermissions(botID)
+	if err != nil {
+		return err // saving now would overwrite the stored roles with nothing
+	}
+	permMutex.Lock()
+	fn(p)
+	raw, err := json.Marshal(p)
+	permMutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return putJSON(bucketPermissions, botID, json.RawMessage(raw))
+}
+
+func deletePermissions(botID string) {
+	permMutex.Lock()
+	delete(permCache, botID)
+	permMutex.Unlock()
+	dataStore.Delete(context.Background(), bucketPermissions, botID)
+}
+
+func (p *BotPermissions) group(chat string, create bool) *GroupPerms {
+	if g := p.Groups[chat]; g != nil || !create {
+		return g
+	}
+	if p.Groups == nil {
+		p.Groups = make(map[string]*GroupPerms)
+	}
+	p.Groups[chat] = &GroupPerms{}
+	return p.Groups[chat]
+}
+
+// identityOf is the key used for users in BotPermissions (see identity.go)
+func identityOf(client *whatsmeow.Client, jid types.JID) string {
+	return resolveIdentity(client, jid)
+}
+
+// ==========================================
+// ⚖️ CHECKS
+// ==========================================
+
+// roleOf: owner > explicit (group, then bot-wide) > group admin = moderator > user
+func roleOf(client *whatsmeow.Client, v *events.Message) Role {
+	if isBotOwner(client, v.Info.Sender) {
+		return RoleOwner
+	}
+	botID := getCleanID(client.Store.ID.User)
+	id := senderIdentity(client, v)
+	chat := v.Info.Chat.String()
+
+	p, err := loadPermissions(botID)
+	if err != nil {
+		return RoleBanned // unknown: deny rather than forget bans
+	}
+	permMutex.RLock()
+	role, explicit := p.Users[id]
+	if g := p.group(chat, false); g != nil {
+		if r, ok := g.Users[id]; ok {
+			role, explicit = r, true
+		}
+	}
+	permMutex.RUnlock()
+
+	if explicit && (role == RoleBanned || role >= RoleModerator) {
+		return role
+	}
+	if v.Info.IsGroup && isGroupAdmin(client, v.Info.Chat, v.Info.Sender) {
+		return RoleModerator
+	}
+	return RoleUser
+}
+
+// requiredRole: group override > bot override > registry default,
+// never below the command's MinRole
+func requiredRole(botID, chat, cmd string) Role {
+	c := lookupCommand(cmd)
+	role, ok := commandOverride(botID, chat, cmd)
+	if !ok {
+		role = RoleUser
+		if c != nil {
+			role = c.Role
+		}
+	}
+	if c != nil && role < c.MinRole {
+		role = c.MinRole
+	}
+	return role
+}
+
+func commandOverride(botID, chat, cmd string) (Role, bool) {
+	p, err := loadPermissions(botID)
+	if err != nil {
+		return RoleOwner, true
+	}
+	permMutex.RLock()
+	defer permMutex.RUnlock()
+	if g := p.group(chat, false); g != nil {
+		if r, ok := g.Commands[cmd]; ok {
+			return r, true
+		}
+	}
+	r, ok := p.Commands[cmd]
+	return r, ok
+}
+
+func groupRestricted(botID, chat string) bool {
+	p, err := loadPermissions(botID)
+	if err != nil {
+		return true
+	}
+	permMutex.RLock()
+	defer permMutex.RUnlock()
+	g := p.group(chat, false)
+	return g != nil && g.Restricted
+}
+
+// ==========================================
+// 🛠️ OWNER COMMANDS
+// ==========================================
+
+// .sudo add|del <user>   .sudo list
+func HandleSudo(client *whatsmeow.Client, v *events.Message, args []string) {
+	if len(args) == 0 {
+		ReplyMessage(client, v, "⚠️ Usage: .sudo add|del @user | .sudo list")
+		return
+	}
+	botID := getCleanID(client.Store.ID.User)
+	switch strings.ToLower(args[0]) {
+	case "list":
+		ReplyMessage(client, v, listRoleHolders(botID, RoleSudo, "👑 *SUDO USERS*"))
+	case "add", "del", "remove":
+		target, ok := GetTarget(v, args[1:])
+		if !ok {
+			ReplyMessage(client, v, "❌ Select user.")
+			return
+		}
+		id := identityOf(client, target)
+		add := strings.EqualFold(args[0], "add")
+		err := updatePermissions(botID, func(p *BotPermissions) {
+			if p.Users == nil {
+				p.Users = make(map[string]Role)
+			}
+			if add {
+				p.Users[id] = RoleSudo
+			} else if p.Users[id] == RoleSudo {
+				delete(p.Users, id)
+			}
+		})
+		if err != nil {
+			ReplyMessage(client, v, "❌ Failed to save.")
+			return
+		}
+		if add {
+			ReplyMessage(client, v, "✅ Sudo added: "+id)
+		} else {
+			ReplyMessage(client, v, "🗑️ Sudo removed: "+id)
+		}
+	default:
+		ReplyMessage(client, v, "⚠️ Usage: .sudo add|del @user | .sudo list")
+	}
+}
+
+// .role set @user moderator|sudo [here] | .role del @user [here] | .role list
+// Sudo users may hand out moderator; only owners touch sudo.
+func HandleRole(client *whatsmeow.Client, v *events.Message, args []string) {
+	usage := "⚠️ Usage:\n.role set @user moderator|sudo [here]\n.role del @user [here]\n.role list"
+	here := len(args) > 0 && strings.EqualFold(args[len(args)-1], "here")
+	if here {
+		args = args[:len(args)-1]
+		if !v.Info.IsGroup {
+			ReplyMessage(client, v, "⚠️ 'here' only works in groups.")
+			return
+		}
+	}
+	if len(args) == 0 {
+		ReplyMessage(client, v, usage)
+		return
+	}
+	botID := getCleanID(client.Store.ID.User)
+	chat := v.Info.Chat.String()
+	action := strings.ToLower(args[0])
+
+	if action == "list" {
+		ReplyMessage(client, v, listRoleHolders(botID, RoleSudo, "👑 *SUDO USERS*")+"\n\n"+
+			listRoleHolders(botID, RoleModerator, "🛡️ *MODERATORS*"))
+		return
+	}
+
+	role := RoleUser
+	switch action {
+	case "set":
+		if len(args) < 3 {
+			ReplyMessage(client, v, usage)
+			return
+		}
+		r, ok := parseRole(args[len(args)-1])
+		if !ok || (r != RoleModerator && r != RoleSudo) {
+			ReplyMessage(client, v, "❌ Role must be moderator or sudo.")
+			return
+		}
+		role, args = r, args[:len(args)-1]
+	case "del", "remove":
+	default:
+		ReplyMessage(client, v, usage)
+		return
+	}
+
+	target, ok := GetTarget(v, args[1:])
+	if !ok {
+		ReplyMessage(client, v, "❌ Select user.")
+		return
+	}
+	if isBotOwner(client, target) {
+		ReplyMessage(client, v, "❌ Owners already have every role.")
+		return
+	}
+	id := identityOf(client, target)
+	isOwner := roleOf(client, v) >= RoleOwner
+
+	denied := false
+	err := updatePermissions(botID, func(p *BotPermissions) {
+		users := &p.Users
+		if here {
+			users = &p.group(chat, true).Users
+		}
+		if *users == nil {
+			*users = make(map[string]Role)
+		}
+		current, has := (*users)[id]
+		if !isOwner && (role >= RoleSudo || (has && current >= RoleSudo)) {
+			denied = true
+			return
+		}
+		if action == "set" {
+			(*users)[id] = role
+		} else if has && current >= RoleModerator {
+			delete(*users, id)
+		}
+	})
+	if denied {
+		ReplyMessage(client, v, "🚫 Only owners can change sudo users.")
+		return
+	}
+	if err != nil {
+		ReplyMessage(client, v, "❌ Failed to save.")
+		return
+	}
+	scope := ""
+	if here {
+		scope = " (this group)"
+	}
+	if action == "set" {
+		ReplyMessage(client, v, fmt.Sprintf("✅ %s is now *%s*%s", id, role, scope))
+	} else {
+		ReplyMessage(client, v, fmt.Sprintf("🗑️ Role removed: %s%s", id, scope))
+	}
+}
+
+// .ban <user> [here]  /  .unban <user> [here]
+func HandleBan(client *whatsmeow.Client, v *events.Message, args []string, ban bool) {
+	here := len(args) > 0 && strings.EqualFold(args[len(args)-1], "here")
+	if here {
+		args = args[:len(args)-1]
+	}
+	target, ok := GetTarget(v, args)
+	if !ok {
+		ReplyMessage(client, v, "❌ Select user.")
+		return
+	}
+	if here && !v.Info.IsGroup {
+		ReplyMessage(client, v, "⚠️ 'here' only works in groups.")
+		return
+	}
+	if isBotOwner(client, target) {
+		ReplyMessage(client, v, "❌ Owners cannot be banned.")
+		return
+	}
+
+	botID := getCleanID(client.Store.ID.User)
+	id := identityOf(client, target)
+	chat := v.Info.Chat.String()
+	err := updatePermissions(botID, func(p *BotPermissions) {
+		users := &p.Users
+		if here {
+			users = &p.group(chat, true).Users
+		}
+		if *users == nil {
+			*users = make(map[string]Role)
+		}
+		if ban {
+			(*users)[id] = RoleBanned
+		} else if (*users)[id] == RoleBanned {
+			delete(*users, id)
+		}
+	})
+	if err != nil {
+		ReplyMessage(client, v, "❌ Failed to save.")
+		return
+	}
+	if ban {
+		ReplyMessage(client, v, "🚫 Banned: "+id)
+	} else {
+		ReplyMessage(client, v, "✅ Unbanned: "+id)
+	}
+}
+
+// .perm set <cmd> <role> [here] | .perm reset <cmd> [here] | .perm restrict on|off | .perm list
+func HandlePerm(client *whatsmeow.Client, v *events.Message, args []string) {
+	usage := "⚠️ Usage:\n.perm set <cmd> <role> [here]\n.perm reset <cmd> [here]\n.perm restrict on|off\n.perm list\nRoles: owner, sudo, moderator, user"
+	if len(args) == 0 {
+		ReplyMessage(client, v, usage)
+		return
+	}
+	botID := getCleanID(client.Store.ID.User)
+	chat := v.Info.Chat.String()
+	here := strings.EqualFold(args[len(args)-1], "here")
+	if here {
+		args = args[:len(args)-1]
+		if !v.Info.IsGroup {
+			ReplyMessage(client, v, "⚠️ 'here' only works in groups.")
+			return
+		}
+	}
+
+	var err error
+	reply := ""
+	switch strings.ToLower(args[0]) {
+	case "set":
+		if len(args) < 3 {
+			ReplyMessage(client, v, usage)
+			return
+		}
+		c := lookupCommand(args[1])
+		if c == nil {
+			ReplyMessage(client, v, "❌ Unknown command: "+args[1])
+			return
+		}
+		cmd := c.Name
+		role, ok := parseRole(args[2])
+		if !ok || role == RoleBanned {
+			ReplyMessage(client, v, "❌ Unknown role: "+args[2])
+			return
+		}
+		if role < c.MinRole {
+			ReplyMessage(client, v, fmt.Sprintf("🚫 %s can't go below *%s*.", cmd, c.MinRole))
+			return
+		}
+		err = updatePermissions(botID, func(p *BotPermissions) {
+			cmds := &p.Commands
+			if here {
+				cmds = &p.group(chat, true).Commands
+			}
+			if *cmds == nil {
+				*cmds = make(map[string]Role)
+			}
+			(*cmds)[cmd] = role
+		})
+		reply = fmt.Sprintf("✅ %s now requires *%s*", cmd, role)
+
+	case "reset":
+		if len(args) < 2 {
+			ReplyMessage(client, v, usage)
+			return
+		}
+		cmd := strings.ToLower(args[1])
+		if c := lookupCommand(cmd); c != nil {
+			cmd = c.Name
+		}
+		err = updatePermissions(botID, func(p *BotPermissions) {
+			if here {
+				if g := p.group(chat, false); g != nil {
+					delete(g.Commands, cmd)
+				}
+				return
+			}
+			delete(p.Commands, cmd)
+		})
+		reply = fmt.Sprintf("♻️ %s reset to *%s*", cmd, requiredRole(botID, chat, cmd))
+
+	case "restrict":
+		if !v.Info.IsGroup || len(args) < 2 {
+			ReplyMessage(client, v, "⚠️ Use in a group: .perm restrict on|off")
+			return
+		}
+		on := strings.EqualFold(args[1], "on")
+		err = updatePermissions(botID, func(p *BotPermissions) {
+			p.group(chat, true).Restricted = on
+		})
+		reply = "🔒 Group restricted to sudo: OFF"
+		if on {
+			reply = "🔒 Group restricted to sudo: ON"
+		}
+
+	case "list":
+		ReplyMessage(client, v, describePermissions(botID, chat))
+		return
+
+	default:
+		ReplyMessage(client, v, usage)
+		return
+	}
+
+	if err != nil {
+		ReplyMessage(client, v, "❌ Failed to save.")
+		return
+	}
+	ReplyMessage(client, v, reply)
+}
+
+func listRoleHolders(botID string, role Role, title string) string {
+	p, err := loadPermissions(botID)
+	if err != nil {
+		return "❌ Failed to load permissions."
+	}
+	permMutex.RLock()
+	var ids []string
+	for id, r := range p.Users {
+		if r == role {
+			ids = append(ids, id)
+		}
+	}
+	permMutex.RUnlock()
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		return title + "\n_none_"
+	}
+	return title + "\n• " + strings.Join(ids, "\n• ")
+}
+
+func describePermissions(botID, chat string) string {
+	p, err := loadPermissions(botID)
+	if err != nil {
+		return "❌ Failed to load permissions."
+	}
+	permMutex.RLock()
+	defer permMutex.RUnlock()
+
+	var sb strings.Builder
+	sb.WriteString("🎖️ *PERMISSIONS*\n")
+	writeRoles := func(title string, m map[string]Role) {
+		if len(m) == 0 {
+			return
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("\n*" + title + "*\n")
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("• %s → %s\n", k, m[k]))
+		}
+	}
+	writeRoles("Users", p.Users)
+	writeRoles("Commands", p.Commands)
+	if g := p.group(chat, false); g != nil {
+		writeRoles("Users (this group)", g.Users)
+		writeRoles("Commands (this group)", g.Commands)
+		if g.Restricted {
+			sb.WriteString("\n🔒 This group is restricted to sudo\n")
+		}
+	}
+	return sb.String()
+}
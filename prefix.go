@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 🔣 PREFIXES (multiple, no-prefix, @mention)
+// ==========================================
+
+// PrefixConfig is stored once per bot (BotSettings.PrefixRules) and
+// optionally per group (BotSettings.GroupPrefixes). On the bot level
+// Prefixes are extra prefixes next to BotSettings.Prefix; on a group a
+// non-nil field replaces the bot's value.
+type PrefixConfig struct {
+	Prefixes []string `json:"prefixes"`
+	NoPrefix []string `json:"no_prefix"` // commands that also run without a prefix
+	Mention  *bool    `json:"mention,omitempty"`
+}
+
+const MaxPrefixLen = 5
+
+// prefixRules is the effective configuration for one chat
+type prefixRules struct {
+	Prefixes []string // longest first; [0] of the unsorted list is used in replies
+	Display  string
+	NoPrefix map[string]bool
+	Mention  bool
+}
+
+func prefixRulesFor(botID, chat string) prefixRules {
+	primary := getPrefix(botID)
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	var bot PrefixConfig
+	var group *PrefixConfig
+	if s := sm.Settings[botID]; s != nil {
+		bot = s.PrefixRules
+		group = s.GroupPrefixes[chat]
+	}
+
+	list := append([]string{primary}, bot.Prefixes...)
+	noPrefix, mention := bot.NoPrefix, bot.Mention != nil && *bot.Mention
+	if group != nil {
+		if group.Prefixes != nil { list = group.Prefixes }
+		if group.NoPrefix != nil { noPrefix = group.NoPrefix }
+		if group.Mention != nil { mention = *group.Mention }
+	}
+
+	r := prefixRules{NoPrefix: make(map[string]bool), Mention: mention, Display: primary}
+	if len(list) > 0 { r.Display = list[0] }
+	r.Prefixes = append(r.Prefixes, list...)
+	sort.SliceStable(r.Prefixes, func(i, j int) bool { return len(r.Prefixes[i]) > len(r.Prefixes[j]) })
+	for _, name := range noPrefix {
+		r.NoPrefix[name] = true
+	}
+	return r
+}
+
+// matchPrefix strips whatever made body a command. prefix is the one to show in replies.
+func matchPrefix(client *whatsmeow.Client, v *events.Message, botID, body string) (rest, prefix string, ok bool) {
+	r := prefixRulesFor(botID, v.Info.Chat.String())
+
+	for _, p := range r.Prefixes {
+		if p != "" && strings.HasPrefix(body, p) {
+			return body[len(p):], p, true
+		}
+	}
+
+	// @bot ping (the mention is typed as @<number> or @<lid>)
+	if r.Mention && strings.HasPrefix(body, "@") {
+		first, after, _ := strings.Cut(body, " ")
+		user := strings.TrimPrefix(first, "@")
+		if user == botID || (client.Store.LID.User != "" && user == client.Store.LID.User) {
+			return strings.TrimSpace(after), r.Display, true
+		}
+	}
+
+	// Whitelisted commands without any prefix
+	if len(r.NoPrefix) > 0 {
+		if fields := strings.Fields(body); len(fields) > 0 {
+			if cmd := lookupCommand(fields[0]); cmd != nil && r.NoPrefix[cmd.Name] {
+				return body, r.Display, true
+			}
+		}
+	}
+	return "", r.Display, false
+}
+
+// editPrefixConfig changes the bot-level config (chat == "") or a group override
+func editPrefixConfig(botID, chat string, fn func(cfg *PrefixConfig, primary *string)) {
+	primary := getPrefix(botID)
+	sm.mu.Lock()
+	if sm.Settings[botID] == nil { sm.Settings[botID] = &BotSettings{Prefix: ".", AlwaysOnline: true, Mode: "public"} }
+	s := sm.Settings[botID]
+	if s.Prefix == "" { s.Prefix = primary }
+
+	if chat == "" {
+		fn(&s.PrefixRules, &s.Prefix)
+	} else {
+		if s.GroupPrefixes == nil { s.GroupPrefixes = make(map[string]*PrefixConfig) }
+		if s.GroupPrefixes[chat] == nil { s.GroupPrefixes[chat] = &PrefixConfig{} }
+		fn(s.GroupPrefixes[chat], nil)
+	}
+	sm.mu.Unlock()
+	saveSettings()
+}
+
+// HandlePrefix: .prefix [add|del <p> | noprefix add|del <cmd> | mention on|off | reset] [--here]
+func HandlePrefix(c *CmdContext) {
+	action := c.Str("action")
+	chat := ""
+	if c.Bool("here") {
+		if !c.Msg.Info.IsGroup {
+			c.Reply("⚠️ --here only works in groups.")
+			return
+		}
+		chat = c.Msg.Info.Chat.String()
+	} else if action != "" && action != "list" && roleOf(c.Client, c.Msg) < RoleOwner {
+		c.Reply("🚫 Only owners can change bot-wide prefixes. Use --here for this group.")
+		return
+	}
+	scope := "bot"
+	if chat != "" { scope = "this group" }
+	value := c.Str("value")
+
+	switch action {
+	case "", "list":
+		c.Reply(describePrefixes(c.BotID, c.Msg.Info.Chat.String()))
+
+	case "add":
+		if value == "" || strings.ContainsAny(value, " \n\t") || utf8.RuneCountInString(value) > MaxPrefixLen {
+			c.Reply(fmt.Sprintf("⚠️ A prefix is 1-%d characters without spaces.", MaxPrefixLen))
+			return
+		}
+		inherited := prefixRulesFor(c.BotID, chat)
+		editPrefixConfig(c.BotID, chat, func(cfg *PrefixConfig, primary *string) {
+			if chat != "" && cfg.Prefixes == nil {
+				cfg.Prefixes = append([]string{inherited.Display}, withoutString(inherited.Prefixes, inherited.Display)...)
+			}
+			if (primary != nil && *primary == value) || containsString(cfg.Prefixes, value) { return }
+			cfg.Prefixes = append(cfg.Prefixes, value)
+		})
+		c.Reply(fmt.Sprintf("✅ Prefix %s added (%s)", value, scope))
+
+	case "del":
+		inherited := prefixRulesFor(c.BotID, chat)
+		failed, missing := false, false
+		editPrefixConfig(c.BotID, chat, func(cfg *PrefixConfig, primary *string) {
+			if chat != "" && cfg.Prefixes == nil {
+				cfg.Prefixes = append([]string{inherited.Display}, withoutString(inherited.Prefixes, inherited.Display)...)
+			}
+			if primary != nil && *primary == value {
+				// The main prefix can only go if another one takes its place
+				if len(cfg.Prefixes) == 0 { failed = true; return }
+				*primary, cfg.Prefixes = cfg.Prefixes[0], cfg.Prefixes[1:]
+				return
+			}
+			if !containsString(cfg.Prefixes, value) { missing = true; return }
+			if chat != "" && len(cfg.Prefixes) == 1 { failed = true; return }
+			cfg.Prefixes = withoutString(cfg.Prefixes, value)
+		})
+		if missing {
+			c.Reply("❌ Not a prefix: " + value)
+			return
+		}
+		if failed {
+			c.Reply("❌ Can't remove the last prefix.")
+			return
+		}
+		c.Reply(fmt.Sprintf("🗑️ Prefix %s removed (%s)", value, scope))
+
+	case "noprefix":
+		cmd := lookupCommand(c.Str("extra"))
+		if (value != "add" && value != "del") || cmd == nil {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %sprefix noprefix add|del <command> [--here]", c.Prefix))
+			return
+		}
+		inherited := prefixRulesFor(c.BotID, chat)
+		editPrefixConfig(c.BotID, chat, func(cfg *PrefixConfig, _ *string) {
+			if chat != "" && cfg.NoPrefix == nil {
+				cfg.NoPrefix = []string{}
+				for name := range inherited.NoPrefix { cfg.NoPrefix = append(cfg.NoPrefix, name) }
+			}
+			cfg.NoPrefix = withoutString(cfg.NoPrefix, cmd.Name)
+			if value == "add" { cfg.NoPrefix = append(cfg.NoPrefix, cmd.Name) }
+		})
+		if value == "add" {
+			c.Reply(fmt.Sprintf("✅ *%s* now works without a prefix (%s)", cmd.Name, scope))
+		} else {
+			c.Reply(fmt.Sprintf("✅ *%s* needs a prefix again (%s)", cmd.Name, scope))
+		}
+
+	case "mention":
+		if value != "on" && value != "off" {
+			c.Reply(fmt.Sprintf("⚠️ Usage: %sprefix mention on|off [--here]", c.Prefix))
+			return
+		}
+		on := value == "on"
+		editPrefixConfig(c.BotID, chat, func(cfg *PrefixConfig, _ *string) { cfg.Mention = &on })
+		c.Reply(fmt.Sprintf("✅ @mention as prefix: %s (%s)", strings.ToUpper(value), scope))
+
+	case "reset":
+		if chat == "" {
+			editPrefixConfig(c.BotID, "", func(cfg *PrefixConfig, _ *string) { *cfg = PrefixConfig{} })
+		} else {
+			sm.mu.Lock()
+			if s := sm.Settings[c.BotID]; s != nil { delete(s.GroupPrefixes, chat) }
+			sm.mu.Unlock()
+			saveSettings()
+		}
+		c.Reply(fmt.Sprintf("♻️ Prefix rules reset (%s)", scope))
+	}
+}
+
+func describePrefixes(botID, chat string) string {
+	r := prefixRulesFor(botID, chat)
+	var names []string
+	for name := range r.NoPrefix { names = append(names, name) }
+	sort.Strings(names)
+
+	noPrefix, mention := "—", "OFF"
+	if len(names) > 0 { noPrefix = strings.Join(names, ", ") }
+	if r.Mention { mention = "ON" }
+
+	// Show in configured order, main prefix first
+	shown := append([]string{r.Display}, withoutString(r.Prefixes, r.Display)...)
+	return fmt.Sprintf("🔣 *Prefixes*\n\n✏️ Prefixes: %s\n🆓 No prefix: %s\n📣 @mention: %s\n\n"+
+		"%sprefix add|del <p> [--here]\n%sprefix noprefix add|del <cmd> [--here]\n%sprefix mention on|off [--here]\n%sprefix reset [--here]",
+		strings.Join(shown, "  "), noPrefix, mention, r.Display, r.Display, r.Display, r.Display)
+}
+
+func withoutString(list []string, s string) []string {
+	out := make([]string, 0, len(list))
+	for _, x := range list {
+		if x != s { out = append(out, x) }
+	}
+	return out
+}
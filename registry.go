@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 📚 COMMAND REGISTRY
+// ==========================================
+
+// Command: ایک کمانڈ کی مکمل تفصیل (dispatch, permissions اور menu سب یہیں سے)
+type Command struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Usage       string // arguments only, e.g. "@user | number"; generated from Args/Flags when empty
+	Category    string
+	Role        Role // minimum role, can be overridden with .perm
+	MinRole     Role // .perm can't go below this (zero = no floor)
+	GroupOnly   bool
+	Args        []ArgSpec  // optional typed spec, see args.go
+	Flags       []FlagSpec
+	Handler     func(c *CmdContext)
+}
+
+// CmdContext is everything a handler needs about one invocation
+type CmdContext struct {
+	Client   *whatsmeow.Client
+	Msg      *events.Message
+	BotID    string
+	Prefix   string
+	Invoked  string // name or alias as typed
+	Args     []string // words, quotes removed
+	FullArgs string   // raw text after the command, newlines kept
+	Parsed   *ParsedArgs
+}
+
+func (c *CmdContext) Reply(text string) { ReplyMessage(c.Client, c.Msg, text) }
+
+func (c *CmdContext) React(emoji string) { react(c.Client, c.Msg.Info.Chat, c.Msg.Info.ID, emoji) }
+
+// Menu categories in display order
+const (
+	CatGeneral   = "General"
+	CatDownloads = "Downloads"
+	CatTools     = "Tools"
+	CatGroup     = "Group"
+	CatOwner     = "Owner"
+	CatPrivate   = "Private"
+)
+
+var categoryOrder = []string{CatGeneral, CatDownloads, CatTools, CatGroup, CatOwner, CatPrivate}
+
+var (
+	commandRegistry = make(map[string]*Command) // name and aliases -> command
+	registryMutex   sync.RWMutex
+)
+
+// RegisterCommand adds c under its name and aliases; duplicates are a programming error
+func RegisterCommand(c *Command) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	for _, key := range append([]string{c.Name}, c.Aliases...) {
+		key = strings.ToLower(key)
+		if _, dup := commandRegistry[key]; dup {
+			panic(fmt.Sprintf("command %q registered twice", key))
+		}
+		commandRegistry[key] = c
+	}
+}
+
+// lookupCommand finds a command by name or alias
+func lookupCommand(name string) *Command {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return commandRegistry[strings.ToLower(name)]
+}
+
+// allCommands returns each command once, sorted by category then name
+func allCommands() []*Command {
+	registryMutex.RLock()
+	seen := make(map[*Command]bool)
+	var out []*Command
+	for _, c := range commandRegistry {
+		if !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	registryMutex.RUnlock()
+
+	rank := make(map[string]int, len(categoryOrder))
+	for i, cat := range categoryOrder {
+		rank[cat] = i
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Category != out[j].Category {
+			return rank[out[i].Category] < rank[out[j].Category]
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// dispatchCommand runs cmd if the sender may use it here
+func dispatchCommand(c *CmdContext, cmd *Command) {
+	if cmd.GroupOnly && !c.Msg.Info.IsGroup {
+		c.Reply("⚠️ This command only works in groups.")
+		return
+	}
+	if !canExecute(c.Client, c.Msg, cmd.Name) {
+		return
+	}
+	if len(cmd.Args) > 0 || len(cmd.Flags) > 0 {
+		parsed, err := parseArgs(c.FullArgs, c.Msg, cmd)
+		if err != nil {
+			c.Reply(fmt.Sprintf("⚠️ %v\n✏️ Usage: *%s%s* %s", err, c.Prefix, cmd.Name, usageOf(cmd)))
+			return
+		}
+		c.Parsed = parsed
+	}
+	fmt.Printf("🚀 [EXEC] Bot:%s | CMD:%s\n", c.BotID, cmd.Name)
+	cmd.Handler(c)
+}
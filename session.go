@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ==========================================
+// 🗑️ SESSION REMOVAL (command, API, supervisor)
+// ==========================================
+
+type RemoveOptions struct {
+	Logout      bool // unlink from WhatsApp too (phone loses the linked device)
+	KeepArchive bool // leave archived messages in the store
+}
+
+// Remove stops botID and wipes it everywhere: RAM, sqlstore device, LID, settings, archive.
+// Returns false if nothing was known about botID.
+func (m *SessionManager) Remove(botID string, opts RemoveOptions) (bool, error) {
+	ctx := context.Background()
+
+	m.mu.Lock()
+	client := m.Clients[botID]
+	_, hadSettings := m.Settings[botID]
+	m.stopLifecycleLocked(botID)
+	delete(m.Clients, botID)
+	delete(m.Settings, botID)
+	m.mu.Unlock()
+
+	if client != nil {
+		if opts.Logout && client.IsConnected() {
+			// Logout also deletes the device from sqlstore
+			if err := client.Logout(ctx); err != nil {
+				fmt.Printf("⚠️ Logout failed for %s: %v\n", botID, err)
+			}
+		}
+		client.Disconnect()
+	}
+
+	removed := deleteBotDevices(botID, types.EmptyJID)
+	forgetLID(botID)
+	deletePermissions(botID)
+	deleteModeration(botID)
+	forgetMenuImage(botID)
+	tenant := botTenant(botID)
+	forgetBotTenant(botID)
+
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	keep(dataStore.DeleteSettings(ctx, botID))
+	keep(dataStore.DeleteLID(ctx, botID))
+	keep(dataStore.Delete(ctx, bucketMenuImages, botID))
+	keep(dataStore.Delete(ctx, bucketMutes, botID))
+	keep(deleteBotKeys(bucketWarnings, botID))
+	keep(deleteBotKeys(bucketGreetings, botID))
+	if !opts.KeepArchive {
+		keep(dataStore.DeleteMessages(ctx, botID))
+	}
+
+	found := client != nil || hadSettings || removed > 0
+	if found {
+		fmt.Printf("🗑️ Session removed: %s\n", botID)
+		broadcastWS(WSMessage{Type: "session_removed", BotID: botID, Tenant: tenant, Payload: map[string]interface{}{
+			"logout":  opts.Logout,
+			"devices": removed,
+		}})
+	}
+	return found, firstErr
+}
+
+// deleteBotDevices deletes every stored device of botID except keep
+func deleteBotDevices(botID string, keep types.JID) int {
+	devices, err := container.GetAllDevices(context.Background())
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, dev := range devices {
+		if dev.ID == nil || *dev.ID == keep || getCleanID(dev.ID.User) != botID {
+			continue
+		}
+		if err := dev.Delete(context.Background()); err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// DELETE /api/sessions/{id}?logout=true&keep_archive=false
+func handleSessionRemove(w http.ResponseWriter, r *http.Request) {
+	botID := getCleanID(strings.TrimPrefix(r.PathValue("id"), "+"))
+	if botID == "" {
+		writeError(w, http.StatusBadRequest, "session id is required")
+		return
+	}
+	if !principalFrom(r).CanAccessBot(botID) {
+		writeError(w, http.StatusNotFound, "Unknown session")
+		return
+	}
+	q := r.URL.Query()
+	logout, _ := strconv.ParseBool(q.Get("logout"))
+	keepArchive, _ := strconv.ParseBool(q.Get("keep_archive"))
+
+	found, err := sm.Remove(botID, RemoveOptions{Logout: logout, KeepArchive: keepArchive})
+	if !found {
+		writeError(w, http.StatusNotFound, "Unknown session")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Session removed with errors: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"removed": botID, "logout": logout})
+}
+
+// ==========================================
+// 👥 OWNERS API
+// ==========================================
+
+// requireSessionAccess resolves {id} and checks the caller's tenant
+func requireSessionAccess(w http.ResponseWriter, r *http.Request) (string, bool) {
+	botID := getCleanID(strings.TrimPrefix(r.PathValue("id"), "+"))
+	sm.mu.RLock()
+	_, live := sm.Clients[botID]
+	_, known := sm.Settings[botID]
+	sm.mu.RUnlock()
+	if (!live && !known) || !principalFrom(r).CanAccessBot(botID) {
+		writeError(w, http.StatusNotFound, "Unknown session")
+		return "", false
+	}
+	return botID, true
+}
+
+// GET /api/sessions/{id}/owners
+func handleListOwners(w http.ResponseWriter, r *http.Request) {
+	botID, ok := requireSessionAccess(w, r)
+	if !ok {
+		return
+	}
+	owners := botOwners(botID)
+	if owners == nil {
+		owners = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"bot_id": botID, "owners": owners})
+}
+
+// POST /api/sessions/{id}/owners {"owner": "923..."}
+func handleAddOwner(w http.ResponseWriter, r *http.Request) {
+	botID, ok := requireSessionAccess(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Owner string `json:"owner"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+	id, valid := normalizeOwner(req.Owner)
+	if !valid || id == botID {
+		writeError(w, http.StatusBadRequest, "Invalid owner number")
+		return
+	}
+	if !addBotOwner(botID, id) {
+		writeError(w, http.StatusConflict, "Already an owner")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"bot_id": botID, "owners": botOwners(botID)})
+}
+
+// DELETE /api/sessions/{id}/owners/{owner}
+func handleDeleteOwner(w http.ResponseWriter, r *http.Request) {
+	botID, ok := requireSessionAccess(w, r)
+	if !ok {
+		return
+	}
+	id, _ := normalizeOwner(r.PathValue("owner"))
+	if !removeBotOwner(botID, id) {
+		writeError(w, http.StatusNotFound, "Not an owner")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"bot_id": botID, "owners": botOwners(botID)})
+}
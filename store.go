@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 💾 STORAGE BACKEND
+// ==========================================
+
+// Store: پورے بوٹ کی سٹیٹ (settings, LIDs, prefixes, archive) ایک جگہ
+type Store interface {
+	// Settings
+	LoadSettings(ctx context.Context) (map[string]*BotSettings, error)
+	SaveSettings(ctx context.Context, botID string, s *BotSettings) error
+	DeleteSettings(ctx context.Context, botID string) error
+
+	// LIDs (keyed by bot phone)
+	LoadLIDs(ctx context.Context) (map[string]BotLIDData, error)
+	SaveLID(ctx context.Context, data BotLIDData) error
+	DeleteLID(ctx context.Context, phone string) error
+
+	// Prefixes ("" when unset)
+	GetPrefix(ctx context.Context, botID string) (string, error)
+	SetPrefix(ctx context.Context, botID, prefix string) error
+
+	// Message archive
+	SaveMessage(ctx context.Context, m *StoredMessage) error
+	ListChats(ctx context.Context, botID string, before Cursor, limit int) ([]ChatSummary, error)
+	HasChat(ctx context.Context, botID, chatID string) (bool, error)
+	ListMessages(ctx context.Context, botID, chatID string, before Cursor, limit int) ([]StoredMessage, error)
+	GetMessage(ctx context.Context, botID, msgID string) (*StoredMessage, error)
+	DeleteMessages(ctx context.Context, botID string) error
+	PruneMessages(ctx context.Context, maxAge time.Duration, maxPerChat int) (int64, error)
+
+	// Generic buckets for smaller features (nil value when missing)
+	Get(ctx context.Context, bucket, key string) ([]byte, error)
+	Put(ctx context.Context, bucket, key string, value []byte) error
+	Delete(ctx context.Context, bucket, key string) error
+	DeletePrefix(ctx context.Context, bucket, prefix string) error
+	List(ctx context.Context, bucket string) (map[string][]byte, error)
+
+	Close() error
+}
+
+// Backend selection: STORE_BACKEND = sqlite (default) | postgres | redis | memory
+const (
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+	BackendMemory   = "memory"
+)
+
+// Archive retention (override with ARCHIVE_MAX_AGE_DAYS / ARCHIVE_MAX_PER_CHAT)
+const (
+	DefaultArchiveMaxAgeDays = 30
+	DefaultArchiveMaxPerChat = 5000
+	ArchivePruneInterval     = 1 * time.Hour
+)
+
+var (
+	dataStore Store
+
+	archiveMaxAge     time.Duration
+	archiveMaxPerChat int
+)
+
+func InitStore() {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("STORE_BACKEND")))
+	if backend == "" {
+		backend = BackendSQLite
+	}
+
+	var err error
+	switch backend {
+	case BackendSQLite:
+		dataStore, err = newSQLiteStore(filepath.Join(VolumeDir, DBName))
+	case BackendPostgres:
+		dataStore, err = newPostgresStore(os.Getenv("DATABASE_URL"))
+	case BackendRedis:
+		dataStore, err = newRedisStore(os.Getenv("REDIS_URL"))
+	case BackendMemory:
+		dataStore = newMemoryStore()
+	default:
+		err = fmt.Errorf("unknown STORE_BACKEND %q", backend)
+	}
+	if err != nil {
+		fmt.Printf("❌ Store Init Failed (%s): %v\n", backend, err)
+		os.Exit(1)
+	}
+	fmt.Printf("💾 Store Ready: %s\n", backend)
+
+	migrateLegacyFiles()
+
+	archiveMaxAge = time.Duration(envInt("ARCHIVE_MAX_AGE_DAYS", DefaultArchiveMaxAgeDays)) * 24 * time.Hour
+	archiveMaxPerChat = envInt("ARCHIVE_MAX_PER_CHAT", DefaultArchiveMaxPerChat)
+	go archivePruneLoop()
+}
+
+// ==========================================
+// 🗄️ MESSAGE ARCHIVE & RETENTION
+// ==========================================
+
+// archiveMessage: handler() سے ہر میسج یہاں آتا ہے
+func archiveMessage(botID string, v *events.Message) {
+	msg := buildStoredMessage(botID, v)
+	if msg == nil {
+		return
+	}
+	if err := dataStore.SaveMessage(context.Background(), msg); err != nil {
+		fmt.Printf("⚠️ Archive save failed (%s): %v\n", botID, err)
+	}
+}
+
+func archivePruneLoop() {
+	pruneArchive()
+	for range time.Tick(ArchivePruneInterval) {
+		pruneArchive()
+	}
+}
+
+func pruneArchive() {
+	removed, err := dataStore.PruneMessages(context.Background(), archiveMaxAge, archiveMaxPerChat)
+	if err != nil {
+		fmt.Printf("⚠️ Archive prune failed: %v\n", err)
+		return
+	}
+	if removed > 0 {
+		fmt.Printf("🧹 Archive pruned %d messages.\n", removed)
+	}
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+// ==========================================
+// 📦 JSON BUCKET HELPERS
+// ==========================================
+
+// getJSON loads bucket/key into v. Returns false when the key does not exist.
+func getJSON(bucket, key string, v interface{}) (bool, error) {
+	raw, err := dataStore.Get(context.Background(), bucket, key)
+	if err != nil || raw == nil {
+		return false, err
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+func putJSON(bucket, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return dataStore.Put(context.Background(), bucket, key, raw)
+}
+
+// deleteBotKeys removes every "botID|..." key of bucket
+func deleteBotKeys(bucket, botID string) error {
+	return dataStore.DeletePrefix(context.Background(), bucket, botID+"|")
+}
+
+// ==========================================
+// 🔁 LEGACY MIGRATION (settings.json / lid_storage.json)
+// ==========================================
+
+func migrateLegacyFiles() {
+	ctx := context.Background()
+
+	settingsPath := filepath.Join(VolumeDir, SettingsFile)
+	if raw, err := os.ReadFile(settingsPath); err == nil {
+		legacy := make(map[string]*BotSettings)
+		if json.Unmarshal(raw, &legacy) == nil {
+			existing, _ := dataStore.LoadSettings(ctx)
+			for botID, s := range legacy {
+				if _, ok := existing[botID]; ok || s == nil {
+					continue
+				}
+				dataStore.SaveSettings(ctx, botID, s)
+			}
+			os.Rename(settingsPath, settingsPath+".migrated")
+			fmt.Printf("🔁 Migrated %d settings from %s\n", len(legacy), SettingsFile)
+		}
+	}
+
+	if raw, err := os.ReadFile(LIDDataFile); err == nil {
+		var legacy LIDStorage
+		if json.Unmarshal(raw, &legacy) == nil {
+			for _, info := range legacy.Bots {
+				dataStore.SaveLID(ctx, info)
+			}
+			os.Rename(LIDDataFile, LIDDataFile+".migrated")
+			fmt.Printf("🔁 Migrated %d LIDs from %s\n", len(legacy.Bots), filepath.Base(LIDDataFile))
+		}
+	}
+}
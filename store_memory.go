@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// 🧠 MEMORY STORE (for tests / throwaway runs)
+// ==========================================
+
+type memoryStore struct {
+	settings map[string]*BotSettings
+	lids     map[string]BotLIDData
+	prefixes map[string]string
+	buckets  map[string]map[string][]byte
+	// botID -> chatID -> messages (oldest first)
+	messages map[string]map[string][]StoredMessage
+	chats    map[string]map[string]ChatSummary
+	mu       sync.RWMutex
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		settings: make(map[string]*BotSettings),
+		lids:     make(map[string]BotLIDData),
+		prefixes: make(map[string]string),
+		buckets:  make(map[string]map[string][]byte),
+		messages: make(map[string]map[string][]StoredMessage),
+		chats:    make(map[string]map[string]ChatSummary),
+	}
+}
+
+func (s *memoryStore) Close() error { return nil }
+
+func (s *memoryStore) LoadSettings(ctx context.Context) (map[string]*BotSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*BotSettings, len(s.settings))
+	for id, bs := range s.settings {
+		cp := *bs
+		out[id] = &cp
+	}
+	return out, nil
+}
+
+func (s *memoryStore) SaveSettings(ctx context.Context, botID string, bs *BotSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *bs
+	s.settings[botID] = &cp
+	return nil
+}
+
+func (s *memoryStore) DeleteSettings(ctx context.Context, botID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.settings, botID)
+	return nil
+}
+
+func (s *memoryStore) LoadLIDs(ctx context.Context) (map[string]BotLIDData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]BotLIDData, len(s.lids))
+	for k, v := range s.lids {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (s *memoryStore) SaveLID(ctx context.Context, d BotLIDData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lids[d.Phone] = d
+	return nil
+}
+
+func (s *memoryStore) DeleteLID(ctx context.Context, phone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.lids, phone)
+	return nil
+}
+
+func (s *memoryStore) GetPrefix(ctx context.Context, botID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.prefixes[botID], nil
+}
+
+func (s *memoryStore) SetPrefix(ctx context.Context, botID, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prefixes[botID] = prefix
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte(nil), v...), nil
+}
+
+func (s *memoryStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string][]byte)
+	}
+	s.buckets[bucket][key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+func (s *memoryStore) DeletePrefix(ctx context.Context, bucket, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.buckets[bucket] {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.buckets[bucket], k)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) List(ctx context.Context, bucket string) (map[string][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string][]byte, len(s.buckets[bucket]))
+	for k, v := range s.buckets[bucket] {
+		out[k] = append([]byte(nil), v...)
+	}
+	return out, nil
+}
+
+// ==========================================
+// 🗄️ MESSAGE ARCHIVE
+// ==========================================
+
+func (s *memoryStore) SaveMessage(ctx context.Context, m *StoredMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.messages[m.BotID] == nil {
+		s.messages[m.BotID] = make(map[string][]StoredMessage)
+		s.chats[m.BotID] = make(map[string]ChatSummary)
+	}
+
+	list := s.messages[m.BotID][m.ChatID]
+	for _, existing := range list {
+		if existing.MessageID == m.MessageID {
+			return nil
+		}
+	}
+	// Oldest first, ties by message ID (same order the cursor uses)
+	idx := sort.Search(len(list), func(i int) bool {
+		return list[i].Timestamp > m.Timestamp || (list[i].Timestamp == m.Timestamp && list[i].MessageID > m.MessageID)
+	})
+	list = append(list, StoredMessage{})
+	copy(list[idx+1:], list[idx:])
+	list[idx] = *m
+	s.messages[m.BotID][m.ChatID] = list
+
+	chat := s.chats[m.BotID][m.ChatID]
+	chat.ID, chat.Type = m.ChatID, chatType(m.ChatID)
+	if name := chatNameFor(m); name != "" {
+		chat.Name = name
+	}
+	if m.Timestamp >= chat.LastMessageAt {
+		chat.LastMessage, chat.LastMessageAt = m.Content, m.Timestamp
+	}
+	s.chats[m.BotID][m.ChatID] = chat
+	return nil
+}
+
+func (s *memoryStore) ListChats(ctx context.Context, botID string, before Cursor, limit int) ([]ChatSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []ChatSummary{}
+	for _, c := range s.chats[botID] {
+		if before.Admits(c.LastMessageAt, c.ID) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].LastMessageAt != out[j].LastMessageAt {
+			return out[i].LastMessageAt > out[j].LastMessageAt
+		}
+		return out[i].ID > out[j].ID
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) HasChat(ctx context.Context, botID, chatID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.chats[botID][chatID]
+	return ok, nil
+}
+
+func (s *memoryStore) ListMessages(ctx context.Context, botID, chatID string, before Cursor, limit int) ([]StoredMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	msgs := s.messages[botID][chatID]
+	end := sort.Search(len(msgs), func(i int) bool { return !before.Admits(msgs[i].Timestamp, msgs[i].MessageID) })
+	start := 0
+	if limit > 0 && end-limit > 0 {
+		start = end - limit
+	}
+	return append([]StoredMessage{}, msgs[start:end]...), nil
+}
+
+func (s *memoryStore) GetMessage(ctx context.Context, botID, msgID string) (*StoredMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, msgs := range s.messages[botID] {
+		for i := range msgs {
+			if msgs[i].MessageID == msgID {
+				m := msgs[i]
+				return &m, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) DeleteMessages(ctx context.Context, botID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, botID)
+	delete(s.chats, botID)
+	return nil
+}
+
+func (s *memoryStore) PruneMessages(ctx context.Context, maxAge time.Duration, maxPerChat int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var removed int64
+	cutoff := time.Now().Add(-maxAge).UnixMilli()
+	for botID, chats := range s.messages {
+		for chatID, msgs := range chats {
+			start := 0
+			if maxAge > 0 {
+				start = sort.Search(len(msgs), func(i int) bool { return msgs[i].Timestamp >= cutoff })
+			}
+			if maxPerChat > 0 && len(msgs)-start > maxPerChat {
+				start = len(msgs) - maxPerChat
+			}
+			removed += int64(start)
+			if start == len(msgs) {
+				delete(chats, chatID)
+				delete(s.chats[botID], chatID)
+				continue
+			}
+			chats[chatID] = msgs[start:]
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ==========================================
+// 🟥 REDIS STORE
+// ==========================================
+
+// Key layout:
+//
+//	settings                  HASH  botID -> BotSettings JSON
+//	lids                      HASH  phone -> BotLIDData JSON
+//	prefix:<bot>              STRING (same key the old Redis fallback used)
+//	kv:<bucket>               HASH  key -> value
+//	msg:<bot>:<id>            STRING message JSON
+//	chatmsgs:<bot>:<chat>     ZSET  score=timestamp member=id
+//	chats:<bot>               ZSET  score=last_message_at member=chatID
+//	chatinfo:<bot>            HASH  chatID -> ChatSummary JSON
+type redisStore struct {
+	rdb *redis.Client
+}
+
+// RedisTxRetries bounds optimistic (WATCH) retries under contention
+const RedisTxRetries = 20
+
+// redisMessage keeps the raw proto next to the public fields
+type redisMessage struct {
+	StoredMessage
+	Raw []byte `json:"raw,omitempty"`
+}
+
+func newRedisStore(url string) (*redisStore, error) {
+	if url == "" {
+		return nil, errors.New("REDIS_URL is not set")
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisStore{rdb: client}, nil
+}
+
+func (s *redisStore) Close() error { return s.rdb.Close() }
+
+func (s *redisStore) LoadSettings(ctx context.Context) (map[string]*BotSettings, error) {
+	all, err := s.rdb.HGetAll(ctx, "settings").Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*BotSettings, len(all))
+	for botID, raw := range all {
+		var bs BotSettings
+		if json.Unmarshal([]byte(raw), &bs) == nil {
+			out[botID] = &bs
+		}
+	}
+	return out, nil
+}
+
+func (s *redisStore) SaveSettings(ctx context.Context, botID string, bs *BotSettings) error {
+	raw, err := json.Marshal(bs)
+	if err != nil {
+		return err
+	}
+	return s.rdb.HSet(ctx, "settings", botID, raw).Err()
+}
+
+func (s *redisStore) DeleteSettings(ctx context.Context, botID string) error {
+	return s.rdb.HDel(ctx, "settings", botID).Err()
+}
+
+func (s *redisStore) LoadLIDs(ctx context.Context) (map[string]BotLIDData, error) {
+	all, err := s.rdb.HGetAll(ctx, "lids").Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]BotLIDData, len(all))
+	for phone, raw := range all {
+		var d BotLIDData
+		if json.Unmarshal([]byte(raw), &d) == nil {
+			out[phone] = d
+		}
+	}
+	return out, nil
+}
+
+func (s *redisStore) SaveLID(ctx context.Context, d BotLIDData) error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return s.rdb.HSet(ctx, "lids", d.Phone, raw).Err()
+}
+
+func (s *redisStore) DeleteLID(ctx context.Context, phone string) error {
+	return s.rdb.HDel(ctx, "lids", phone).Err()
+}
+
+func (s *redisStore) GetPrefix(ctx context.Context, botID string) (string, error) {
+	p, err := s.rdb.Get(ctx, "prefix:"+botID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return p, err
+}
+
+func (s *redisStore) SetPrefix(ctx context.Context, botID, prefix string) error {
+	return s.rdb.Set(ctx, "prefix:"+botID, prefix, 0).Err()
+}
+
+func (s *redisStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	v, err := s.rdb.HGet(ctx, "kv:"+bucket, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return v, err
+}
+
+func (s *redisStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	return s.rdb.HSet(ctx, "kv:"+bucket, key, value).Err()
+}
+
+func (s *redisStore) Delete(ctx context.Context, bucket, key string) error {
+	return s.rdb.HDel(ctx, "kv:"+bucket, key).Err()
+}
+
+// DeletePrefix walks the bucket hash with HSCAN instead of loading it whole
+func (s *redisStore) DeletePrefix(ctx context.Context, bucket, prefix string) error {
+	key := "kv:" + bucket
+	iter := s.rdb.HScan(ctx, key, 0, redisGlobEscape(prefix)+"*", 200).Iterator()
+	var fields []string
+	for i := 0; iter.Next(ctx); i++ {
+		if i%2 == 0 { // HSCAN yields field, value, field, value...
+			fields = append(fields, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil || len(fields) == 0 {
+		return err
+	}
+	return s.rdb.HDel(ctx, key, fields...).Err()
+}
+
+// redisGlobEscape quotes the MATCH pattern characters in s
+func redisGlobEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func (s *redisStore) List(ctx context.Context, bucket string) (map[string][]byte, error) {
+	all, err := s.rdb.HGetAll(ctx, "kv:"+bucket).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(all))
+	for k, v := range all {
+		out[k] = []byte(v)
+	}
+	return out, nil
+}
+
+// ==========================================
+// 🗄️ MESSAGE ARCHIVE
+// ==========================================
+
+func (s *redisStore) SaveMessage(ctx context.Context, m *StoredMessage) error {
+	rm := redisMessage{StoredMessage: *m}
+	if m.Type != "text" && m.raw != nil {
+		rm.Raw = marshalRaw(m.raw)
+	}
+	raw, err := json.Marshal(rm)
+	if err != nil {
+		return err
+	}
+
+	msgKey := "msg:" + m.BotID + ":" + m.MessageID
+	created, err := s.rdb.SetNX(ctx, msgKey, raw, 0).Result()
+	if err != nil || !created {
+		return err
+	}
+
+	// chatinfo is read-modify-write: WATCH it so concurrent saves to the
+	// same bot retry instead of overwriting each other's last_message
+	infoKey := "chatinfo:" + m.BotID
+	update := func(tx *redis.Tx) error {
+		chat := ChatSummary{ID: m.ChatID, Type: chatType(m.ChatID)}
+		prev, err := tx.HGet(ctx, infoKey, m.ChatID).Bytes()
+		if err == nil {
+			json.Unmarshal(prev, &chat)
+		} else if err != redis.Nil {
+			return err
+		}
+		if name := chatNameFor(m); name != "" {
+			chat.Name = name
+		}
+		if m.Timestamp >= chat.LastMessageAt {
+			chat.LastMessage, chat.LastMessageAt = m.Content, m.Timestamp
+		}
+		chatRaw, _ := json.Marshal(chat)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZAdd(ctx, "chatmsgs:"+m.BotID+":"+m.ChatID, redis.Z{Score: float64(m.Timestamp), Member: m.MessageID})
+			pipe.ZAdd(ctx, "chats:"+m.BotID, redis.Z{Score: float64(chat.LastMessageAt), Member: m.ChatID})
+			pipe.HSet(ctx, infoKey, m.ChatID, chatRaw)
+			return nil
+		})
+		return err
+	}
+	for attempt := 0; attempt < RedisTxRetries; attempt++ {
+		if err = s.rdb.Watch(ctx, update, infoKey); err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *redisStore) ListChats(ctx context.Context, botID string, before Cursor, limit int) ([]ChatSummary, error) {
+	ids, err := s.zPage(ctx, "chats:"+botID, before, limit)
+	if err != nil || len(ids) == 0 {
+		return []ChatSummary{}, err
+	}
+	raws, err := s.rdb.HMGet(ctx, "chatinfo:"+botID, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ChatSummary, 0, len(raws))
+	for i, raw := range raws {
+		c := ChatSummary{ID: ids[i], Type: chatType(ids[i])}
+		if str, ok := raw.(string); ok {
+			json.Unmarshal([]byte(str), &c)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (s *redisStore) HasChat(ctx context.Context, botID, chatID string) (bool, error) {
+	return s.rdb.HExists(ctx, "chatinfo:"+botID, chatID).Result()
+}
+
+func (s *redisStore) ListMessages(ctx context.Context, botID, chatID string, before Cursor, limit int) ([]StoredMessage, error) {
+	ids, err := s.zPage(ctx, "chatmsgs:"+botID+":"+chatID, before, limit)
+	if err != nil || len(ids) == 0 {
+		return []StoredMessage{}, err
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = "msg:" + botID + ":" + id
+	}
+	raws, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]StoredMessage, 0, len(raws))
+	for _, raw := range raws {
+		if m := decodeRedisMessage(raw); m != nil {
+			out = append(out, *m)
+		}
+	}
+	reverseMessages(out)
+	return out, nil
+}
+
+func (s *redisStore) GetMessage(ctx context.Context, botID, msgID string) (*StoredMessage, error) {
+	raw, err := s.rdb.Get(ctx, "msg:"+botID+":"+msgID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeRedisMessage(raw), nil
+}
+
+func (s *redisStore) DeleteMessages(ctx context.Context, botID string) error {
+	chats, err := s.rdb.ZRange(ctx, "chats:"+botID, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, chatID := range chats {
+		ids, err := s.rdb.ZRange(ctx, "chatmsgs:"+botID+":"+chatID, 0, -1).Result()
+		if err != nil {
+			return err
+		}
+		s.deleteMessageKeys(ctx, botID, ids)
+		s.rdb.Del(ctx, "chatmsgs:"+botID+":"+chatID)
+	}
+	return s.rdb.Del(ctx, "chats:"+botID, "chatinfo:"+botID).Err()
+}
+
+func (s *redisStore) PruneMessages(ctx context.Context, maxAge time.Duration, maxPerChat int) (int64, error) {
+	var removed int64
+	cutoff := strconv.FormatInt(time.Now().Add(-maxAge).UnixMilli(), 10)
+
+	iter := s.rdb.Scan(ctx, 0, "chatmsgs:*", 500).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		parts := strings.SplitN(strings.TrimPrefix(key, "chatmsgs:"), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		botID := parts[0]
+
+		var stale []string
+		if maxAge > 0 {
+			ids, _ := s.rdb.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "-inf", Max: "(" + cutoff}).Result()
+			stale = append(stale, ids...)
+		}
+		if maxPerChat > 0 {
+			ids, _ := s.rdb.ZRange(ctx, key, 0, int64(-maxPerChat-1)).Result()
+			stale = append(stale, ids...)
+		}
+		if len(stale) == 0 {
+			continue
+		}
+		members := make([]interface{}, len(stale))
+		for i, id := range stale {
+			members[i] = id
+		}
+		n, _ := s.rdb.ZRem(ctx, key, members...).Result()
+		removed += n
+		s.deleteMessageKeys(ctx, botID, stale)
+
+		// A chat without messages leaves the chat list too (like the SQL store)
+		if left, err := s.rdb.ZCard(ctx, key).Result(); err == nil && left == 0 {
+			pipe := s.rdb.TxPipeline()
+			pipe.Del(ctx, key)
+			pipe.ZRem(ctx, "chats:"+botID, parts[1])
+			pipe.HDel(ctx, "chatinfo:"+botID, parts[1])
+			pipe.Exec(ctx)
+		}
+	}
+	return removed, iter.Err()
+}
+
+func (s *redisStore) deleteMessageKeys(ctx context.Context, botID string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, "msg:"+botID+":"+id)
+	}
+	s.rdb.Del(ctx, keys...)
+}
+
+func decodeRedisMessage(raw interface{}) *StoredMessage {
+	str, ok := raw.(string)
+	if !ok {
+		return nil
+	}
+	var rm redisMessage
+	if json.Unmarshal([]byte(str), &rm) != nil {
+		return nil
+	}
+	m := rm.StoredMessage
+	m.raw = unmarshalRaw(rm.Raw)
+	return &m
+}
+
+// zPage reads a ZSET newest first, starting below the (score, member) cursor.
+// Members with the cursor's own score come back in reverse byte order, the
+// same tie-break the SQL and memory stores use.
+func (s *redisStore) zPage(ctx context.Context, key string, before Cursor, limit int) ([]string, error) {
+	if before.IsZero() {
+		return s.rdb.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Max: "+inf", Min: "-inf", Count: int64(limit)}).Result()
+	}
+
+	score := strconv.FormatInt(before.Timestamp, 10)
+	var out []string
+	if before.ID != "" {
+		same, err := s.rdb.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Max: score, Min: score}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range same {
+			if member < before.ID && len(out) < limit {
+				out = append(out, member)
+			}
+		}
+		if len(out) >= limit {
+			return out, nil
+		}
+	}
+	rest, err := s.rdb.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+		Max: "(" + score, Min: "-inf", Count: int64(limit - len(out)),
+	}).Result()
+	return append(out, rest...), err
+}
@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/lib/pq"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// ==========================================
+// 🗄️ SQL STORE (SQLite + Postgres)
+// ==========================================
+
+type sqlStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS bot_settings (
+	bot_id TEXT PRIMARY KEY,
+	data   TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bot_lids (
+	phone        TEXT PRIMARY KEY,
+	lid          TEXT NOT NULL,
+	extracted_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bot_prefixes (
+	bot_id TEXT PRIMARY KEY,
+	prefix TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS kv_store (
+	bucket TEXT NOT NULL,
+	key    TEXT NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE TABLE IF NOT EXISTS archived_messages (
+	bot_id        TEXT    NOT NULL,
+	chat_id       TEXT    NOT NULL,
+	message_id    TEXT    NOT NULL,
+	sender        TEXT    NOT NULL,
+	sender_name   TEXT    NOT NULL DEFAULT '',
+	is_from_me    INTEGER NOT NULL DEFAULT 0,
+	is_group      INTEGER NOT NULL DEFAULT 0,
+	type          TEXT    NOT NULL,
+	content       TEXT    NOT NULL DEFAULT '',
+	is_sticker    INTEGER NOT NULL DEFAULT 0,
+	media_mime    TEXT    NOT NULL DEFAULT '',
+	media_size    INTEGER NOT NULL DEFAULT 0,
+	quoted_id     TEXT    NOT NULL DEFAULT '',
+	quoted_msg    TEXT    NOT NULL DEFAULT '',
+	quoted_sender TEXT    NOT NULL DEFAULT '',
+	timestamp     INTEGER NOT NULL,
+	raw           BLOB,
+	PRIMARY KEY (bot_id, message_id)
+);
+CREATE INDEX IF NOT EXISTS idx_archived_chat_ts ON archived_messages (bot_id, chat_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_archived_ts ON archived_messages (timestamp);
+CREATE TABLE IF NOT EXISTS archived_chats (
+	bot_id          TEXT    NOT NULL,
+	chat_id         TEXT    NOT NULL,
+	name            TEXT    NOT NULL DEFAULT '',
+	last_message    TEXT    NOT NULL DEFAULT '',
+	last_message_at INTEGER NOT NULL,
+	PRIMARY KEY (bot_id, chat_id)
+);
+CREATE INDEX IF NOT EXISTS idx_archived_chats_ts ON archived_chats (bot_id, last_message_at);
+`
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS bot_settings (
+	bot_id TEXT PRIMARY KEY,
+	data   TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bot_lids (
+	phone        TEXT PRIMARY KEY,
+	lid          TEXT NOT NULL,
+	extracted_at BIGINT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bot_prefixes (
+	bot_id TEXT PRIMARY KEY,
+	prefix TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS kv_store (
+	bucket TEXT  NOT NULL,
+	key    TEXT  NOT NULL,
+	value  BYTEA NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE TABLE IF NOT EXISTS archived_messages (
+	bot_id        TEXT    NOT NULL,
+	chat_id       TEXT    NOT NULL,
+	message_id    TEXT    NOT NULL,
+	sender        TEXT    NOT NULL,
+	sender_name   TEXT    NOT NULL DEFAULT '',
+	is_from_me    BOOLEAN NOT NULL DEFAULT FALSE,
+	is_group      BOOLEAN NOT NULL DEFAULT FALSE,
+	type          TEXT    NOT NULL,
+	content       TEXT    NOT NULL DEFAULT '',
+	is_sticker    BOOLEAN NOT NULL DEFAULT FALSE,
+	media_mime    TEXT    NOT NULL DEFAULT '',
+	media_size    BIGINT  NOT NULL DEFAULT 0,
+	quoted_id     TEXT    NOT NULL DEFAULT '',
+	quoted_msg    TEXT    NOT NULL DEFAULT '',
+	quoted_sender TEXT    NOT NULL DEFAULT '',
+	timestamp     BIGINT  NOT NULL,
+	raw           BYTEA,
+	PRIMARY KEY (bot_id, message_id)
+);
+CREATE INDEX IF NOT EXISTS idx_archived_chat_ts ON archived_messages (bot_id, chat_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_archived_ts ON archived_messages (timestamp);
+CREATE TABLE IF NOT EXISTS archived_chats (
+	bot_id          TEXT   NOT NULL,
+	chat_id         TEXT   NOT NULL,
+	name            TEXT   NOT NULL DEFAULT '',
+	last_message    TEXT   NOT NULL DEFAULT '',
+	last_message_at BIGINT NOT NULL,
+	PRIMARY KEY (bot_id, chat_id)
+);
+CREATE INDEX IF NOT EXISTS idx_archived_chats_ts ON archived_chats (bot_id, last_message_at);
+`
+
+// SQLiteOptions is used by both pools on sessions.db (whatsmeow's and ours):
+// WAL lets readers run next to a writer and busy_timeout makes writers queue.
+const SQLiteOptions = "?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL"
+
+// newSQLiteStore opens the same file whatsmeow uses (sessions.db)
+func newSQLiteStore(path string) (*sqlStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+SQLiteOptions)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlStore{db: db, dialect: BackendSQLite}, nil
+}
+
+func newPostgresStore(dsn string) (*sqlStore, error) {
+	if dsn == "" {
+		return nil, errors.New("DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(postgresSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlStore{db: db, dialect: BackendPostgres}, nil
+}
+
+// q rewrites ? placeholders to $n for Postgres
+func (s *sqlStore) q(query string) string {
+	if s.dialect != BackendPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *sqlStore) greatest() string {
+	if s.dialect == BackendPostgres {
+		return "GREATEST"
+	}
+	return "MAX"
+}
+
+func (s *sqlStore) Close() error { return s.db.Close() }
+
+// ==========================================
+// ⚙️ SETTINGS / LIDS / PREFIXES
+// ==========================================
+
+func (s *sqlStore) LoadSettings(ctx context.Context) (map[string]*BotSettings, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT bot_id, data FROM bot_settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]*BotSettings)
+	for rows.Next() {
+		var botID, raw string
+		if err := rows.Scan(&botID, &raw); err != nil {
+			return nil, err
+		}
+		var bs BotSettings
+		if json.Unmarshal([]byte(raw), &bs) == nil {
+			out[botID] = &bs
+		}
+	}
+	return out, rows.Err()
+}
+
+func (s *sqlStore) SaveSettings(ctx context.Context, botID string, bs *BotSettings) error {
+	raw, err := json.Marshal(bs)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.q(`
+		INSERT INTO bot_settings (bot_id, data) VALUES (?, ?)
+		ON CONFLICT (bot_id) DO UPDATE SET data = excluded.data`), botID, string(raw))
+	return err
+}
+
+func (s *sqlStore) DeleteSettings(ctx context.Context, botID string) error {
+	_, err := s.db.ExecContext(ctx, s.q(`DELETE FROM bot_settings WHERE bot_id = ?`), botID)
+	return err
+}
+
+func (s *sqlStore) LoadLIDs(ctx context.Context) (map[string]BotLIDData, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT phone, lid, extracted_at FROM bot_lids`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]BotLIDData)
+	for rows.Next() {
+		var d BotLIDData
+		var ts int64
+		if err := rows.Scan(&d.Phone, &d.LID, &ts); err != nil {
+			return nil, err
+		}
+		d.ExtractedAt = time.Unix(ts, 0)
+		out[d.Phone] = d
+	}
+	return out, rows.Err()
+}
+
+func (s *sqlStore) SaveLID(ctx context.Context, d BotLIDData) error {
+	_, err := s.db.ExecContext(ctx, s.q(`
+		INSERT INTO bot_lids (phone, lid, extracted_at) VALUES (?, ?, ?)
+		ON CONFLICT (phone) DO UPDATE SET lid = excluded.lid, extracted_at = excluded.extracted_at`),
+		d.Phone, d.LID, d.ExtractedAt.Unix())
+	return err
+}
+
+func (s *sqlStore) DeleteLID(ctx context.Context, phone string) error {
+	_, err := s.db.ExecContext(ctx, s.q(`DELETE FROM bot_lids WHERE phone = ?`), phone)
+	return err
+}
+
+func (s *sqlStore) GetPrefix(ctx context.Context, botID string) (string, error) {
+	var p string
+	err := s.db.QueryRowContext(ctx, s.q(`SELECT prefix FROM bot_prefixes WHERE bot_id = ?`), botID).Scan(&p)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return p, err
+}
+
+func (s *sqlStore) SetPrefix(ctx context.Context, botID, prefix string) error {
+	_, err := s.db.ExecContext(ctx, s.q(`
+		INSERT INTO bot_prefixes (bot_id, prefix) VALUES (?, ?)
+		ON CONFLICT (bot_id) DO UPDATE SET prefix = excluded.prefix`), botID, prefix)
+	return err
+}
+
+// ==========================================
+// 📦 GENERIC BUCKETS
+// ==========================================
+
+func (s *sqlStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	var v []byte
+	err := s.db.QueryRowContext(ctx, s.q(`SELECT value FROM kv_store WHERE bucket = ? AND key = ?`), bucket, key).Scan(&v)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+func (s *sqlStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	_, err := s.db.ExecContext(ctx, s.q(`
+		INSERT INTO kv_store (bucket, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`), bucket, key, value)
+	return err
+}
+
+func (s *sqlStore) Delete(ctx context.Context, bucket, key string) error {
+	_, err := s.db.ExecContext(ctx, s.q(`DELETE FROM kv_store WHERE bucket = ? AND key = ?`), bucket, key)
+	return err
+}
+
+// DeletePrefix compares with substr rather than LIKE, so % and _ in keys need no escaping
+func (s *sqlStore) DeletePrefix(ctx context.Context, bucket, prefix string) error {
+	_, err := s.db.ExecContext(ctx, s.q(`DELETE FROM kv_store WHERE bucket = ? AND substr(key, 1, ?) = ?`),
+		bucket, utf8.RuneCountInString(prefix), prefix)
+	return err
+}
+
+func (s *sqlStore) List(ctx context.Context, bucket string) (map[string][]byte, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT key, value FROM kv_store WHERE bucket = ?`), bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string][]byte)
+	for rows.Next() {
+		var k string
+		var v []byte
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, rows.Err()
+}
+
+// ==========================================
+// 🗄️ MESSAGE ARCHIVE
+// ==========================================
+
+func (s *sqlStore) SaveMessage(ctx context.Context, m *StoredMessage) error {
+	// Only media messages need the raw proto (for later download)
+	var raw []byte
+	if m.Type != "text" && m.raw != nil {
+		raw = marshalRaw(m.raw)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, s.q(`
+		INSERT INTO archived_messages
+			(bot_id, chat_id, message_id, sender, sender_name, is_from_me, is_group, type, content,
+			 is_sticker, media_mime, media_size, quoted_id, quoted_msg, quoted_sender, timestamp, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (bot_id, message_id) DO NOTHING`),
+		m.BotID, m.ChatID, m.MessageID, m.Sender, m.SenderName, m.IsFromMe, m.IsGroup, m.Type, m.Content,
+		m.IsSticker, m.MediaMime, int64(m.MediaSize), m.QuotedID, m.QuotedMsg, m.QuotedSender, m.Timestamp, raw)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, s.q(fmt.Sprintf(`
+		INSERT INTO archived_chats (bot_id, chat_id, name, last_message, last_message_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (bot_id, chat_id) DO UPDATE SET
+			name = CASE WHEN excluded.name != '' THEN excluded.name ELSE archived_chats.name END,
+			last_message = CASE WHEN excluded.last_message_at >= archived_chats.last_message_at THEN excluded.last_message ELSE archived_chats.last_message END,
+			last_message_at = %s(archived_chats.last_message_at, excluded.last_message_at)`, s.greatest())),
+		m.BotID, m.ChatID, chatNameFor(m), m.Content, m.Timestamp)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) ListChats(ctx context.Context, botID string, before Cursor, limit int) ([]ChatSummary, error) {
+	before = openCursor(before)
+	rows, err := s.db.QueryContext(ctx, s.q(`
+		SELECT chat_id, name, last_message, last_message_at FROM archived_chats
+		WHERE bot_id = ? AND (last_message_at < ? OR (last_message_at = ? AND chat_id < ?))
+		ORDER BY last_message_at DESC, chat_id DESC LIMIT ?`),
+		botID, before.Timestamp, before.Timestamp, before.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ChatSummary{}
+	for rows.Next() {
+		var c ChatSummary
+		if err := rows.Scan(&c.ID, &c.Name, &c.LastMessage, &c.LastMessageAt); err != nil {
+			return nil, err
+		}
+		c.Type = chatType(c.ID)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqlStore) HasChat(ctx context.Context, botID, chatID string) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, s.q(
+		`SELECT COUNT(*) FROM archived_chats WHERE bot_id = ? AND chat_id = ?`), botID, chatID).Scan(&n)
+	return n > 0, err
+}
+
+func (s *sqlStore) ListMessages(ctx context.Context, botID, chatID string, before Cursor, limit int) ([]StoredMessage, error) {
+	before = openCursor(before)
+	rows, err := s.db.QueryContext(ctx, s.q(`
+		SELECT `+archiveColumns+` FROM archived_messages
+		WHERE bot_id = ? AND chat_id = ? AND (timestamp < ? OR (timestamp = ? AND message_id < ?))
+		ORDER BY timestamp DESC, message_id DESC LIMIT ?`),
+		botID, chatID, before.Timestamp, before.Timestamp, before.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []StoredMessage{}
+	for rows.Next() {
+		m, err := scanArchived(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	reverseMessages(out)
+	return out, nil
+}
+
+func (s *sqlStore) GetMessage(ctx context.Context, botID, msgID string) (*StoredMessage, error) {
+	m, err := scanArchived(s.db.QueryRowContext(ctx, s.q(`
+		SELECT `+archiveColumns+` FROM archived_messages
+		WHERE bot_id = ? AND message_id = ?
+		ORDER BY timestamp DESC LIMIT 1`), botID, msgID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+func (s *sqlStore) DeleteMessages(ctx context.Context, botID string) error {
+	if _, err := s.db.ExecContext(ctx, s.q(`DELETE FROM archived_messages WHERE bot_id = ?`), botID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, s.q(`DELETE FROM archived_chats WHERE bot_id = ?`), botID)
+	return err
+}
+
+func (s *sqlStore) PruneMessages(ctx context.Context, maxAge time.Duration, maxPerChat int) (int64, error) {
+	var removed int64
+
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge).UnixMilli()
+		res, err := s.db.ExecContext(ctx, s.q(`DELETE FROM archived_messages WHERE timestamp < ?`), cutoff)
+		if err != nil {
+			return removed, err
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+		if _, err := s.db.ExecContext(ctx, s.q(`DELETE FROM archived_chats WHERE last_message_at < ?`), cutoff); err != nil {
+			return removed, err
+		}
+	}
+
+	if maxPerChat > 0 {
+		res, err := s.db.ExecContext(ctx, s.q(`
+			DELETE FROM archived_messages WHERE (bot_id, message_id) IN (
+				SELECT bot_id, message_id FROM (
+					SELECT bot_id, message_id,
+						ROW_NUMBER() OVER (PARTITION BY bot_id, chat_id ORDER BY timestamp DESC, message_id DESC) AS rn
+					FROM archived_messages
+				) AS ranked WHERE rn > ?
+			)`), maxPerChat)
+		if err != nil {
+			return removed, err
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+	return removed, nil
+}
+
+// ==========================================
+// 🛠️ HELPERS
+// ==========================================
+
+const archiveColumns = `bot_id, chat_id, message_id, sender, sender_name, is_from_me, is_group, type, content,
+	is_sticker, media_mime, media_size, quoted_id, quoted_msg, quoted_sender, timestamp, raw`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanArchived(row rowScanner) (*StoredMessage, error) {
+	var m StoredMessage
+	var raw []byte
+	var size int64
+	err := row.Scan(&m.BotID, &m.ChatID, &m.MessageID, &m.Sender, &m.SenderName, &m.IsFromMe, &m.IsGroup,
+		&m.Type, &m.Content, &m.IsSticker, &m.MediaMime, &size, &m.QuotedID, &m.QuotedMsg,
+		&m.QuotedSender, &m.Timestamp, &raw)
+	if err != nil {
+		return nil, err
+	}
+	m.MediaSize = uint64(size)
+	m.raw = unmarshalRaw(raw)
+	return &m, nil
+}
+
+func marshalRaw(m *waProto.Message) []byte {
+	raw, err := proto.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+func unmarshalRaw(raw []byte) *waProto.Message {
+	if len(raw) == 0 {
+		return nil
+	}
+	var pm waProto.Message
+	if proto.Unmarshal(raw, &pm) != nil {
+		return nil
+	}
+	return &pm
+}
+
+// chatNameFor: private chats are named after the other side's push name
+func chatNameFor(m *StoredMessage) string {
+	if !m.IsGroup && !m.IsFromMe {
+		return m.SenderName
+	}
+	return ""
+}
+
+// openCursor turns "no cursor" into one above every row
+func openCursor(c Cursor) Cursor {
+	if c.IsZero() {
+		return Cursor{Timestamp: 1<<63 - 1}
+	}
+	return c
+}
+
+func reverseMessages(msgs []StoredMessage) {
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+}
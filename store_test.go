@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Every backend must behave the same; Redis and Postgres need a server and
+// run only where one is configured, so the suite covers memory and SQLite.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	sqlite, err := newSQLiteStore(filepath.Join(t.TempDir(), DBName))
+	if err != nil {
+		t.Fatalf("sqlite store: %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	return map[string]Store{BackendMemory: newMemoryStore(), BackendSQLite: sqlite}
+}
+
+func textMessage(chat, id string, ts int64) *StoredMessage {
+	return &StoredMessage{
+		BotID: "923000000000", ChatID: chat, MessageID: id, Sender: chat,
+		Type: "text", Content: "msg " + id, Timestamp: ts,
+	}
+}
+
+func TestStoreBuckets(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if v, err := s.Get(ctx, "b", "missing"); err != nil || v != nil {
+				t.Fatalf("Get(missing) = %q, %v; want nil, nil", v, err)
+			}
+			s.Put(ctx, "b", "k1", []byte("one"))
+			s.Put(ctx, "b", "k1", []byte("uno"))
+			s.Put(ctx, "b", "k2", []byte("two"))
+			s.Put(ctx, "other", "k1", []byte("x"))
+
+			if v, _ := s.Get(ctx, "b", "k1"); string(v) != "uno" {
+				t.Errorf("Get(k1) = %q, want uno", v)
+			}
+			s.Delete(ctx, "b", "k2")
+			all, err := s.List(ctx, "b")
+			if err != nil || len(all) != 1 || string(all["k1"]) != "uno" {
+				t.Errorf("List(b) = %v, %v; want only k1", all, err)
+			}
+
+			// Prefix deletes stay inside the bucket and treat % _ * as plain text
+			for _, k := range []string{"bot1|a", "bot1|b", "bot10|a", "b%t|a", "b*t|a"} {
+				s.Put(ctx, "p", k, []byte("x"))
+			}
+			s.Put(ctx, "other", "bot1|a", []byte("x"))
+			s.DeletePrefix(ctx, "p", "bot1|")
+			// As LIKE or MATCH patterns these would hit every key left; literally they match none
+			for _, wild := range []string{"%", "*", "b_", "b?"} {
+				s.DeletePrefix(ctx, "p", wild)
+			}
+			left, _ := s.List(ctx, "p")
+			if len(left) != 3 || left["bot10|a"] == nil || left["b%t|a"] == nil || left["b*t|a"] == nil {
+				t.Errorf("after DeletePrefix, bucket p = %v", left)
+			}
+			s.DeletePrefix(ctx, "p", "b%t|")
+			if left, _ := s.List(ctx, "p"); len(left) != 2 || left["b%t|a"] != nil {
+				t.Errorf("after DeletePrefix(b%%t|), bucket p = %v", left)
+			}
+			if v, _ := s.Get(ctx, "other", "bot1|a"); v == nil {
+				t.Error("DeletePrefix touched another bucket")
+			}
+		})
+	}
+}
+
+// Messages sharing a timestamp must all come back exactly once across pages
+func TestStoreMessagePaging(t *testing.T) {
+	ctx := context.Background()
+	const chat = "923111111111@s.whatsapp.net"
+	base := time.Now().Add(-time.Hour).Truncate(time.Second).UnixMilli()
+
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			want := 0
+			for i := 0; i < 7; i++ {
+				ts := base + int64(i/3)*1000 // three messages per second
+				s.SaveMessage(ctx, textMessage(chat, fmt.Sprintf("ID%02d", i), ts))
+				want++
+			}
+			s.SaveMessage(ctx, textMessage(chat, "ID00", base)) // duplicate is ignored
+
+			seen := make(map[string]bool)
+			var before Cursor
+			for page := 0; page < 10; page++ {
+				msgs, err := s.ListMessages(ctx, "923000000000", chat, before, 2)
+				if err != nil {
+					t.Fatalf("ListMessages: %v", err)
+				}
+				if len(msgs) == 0 {
+					break
+				}
+				for i := 1; i < len(msgs); i++ {
+					if !(Cursor{Timestamp: msgs[i].Timestamp, ID: msgs[i].MessageID}).Admits(msgs[i-1].Timestamp, msgs[i-1].MessageID) {
+						t.Fatalf("page not oldest first: %s before %s", msgs[i-1].MessageID, msgs[i].MessageID)
+					}
+				}
+				for _, m := range msgs {
+					if seen[m.MessageID] {
+						t.Fatalf("%s returned twice", m.MessageID)
+					}
+					seen[m.MessageID] = true
+				}
+				before = Cursor{Timestamp: msgs[0].Timestamp, ID: msgs[0].MessageID}
+			}
+			if len(seen) != want {
+				t.Errorf("paged through %d messages, want %d", len(seen), want)
+			}
+		})
+	}
+}
+
+func TestStoreChatPaging(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Now().Add(-time.Hour).Truncate(time.Second).UnixMilli()
+
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 4; i++ {
+				s.SaveMessage(ctx, textMessage(fmt.Sprintf("92300000000%d@s.whatsapp.net", i), fmt.Sprintf("C%d", i), ts))
+			}
+
+			var got []string
+			var before Cursor
+			for page := 0; page < 10; page++ {
+				chats, err := s.ListChats(ctx, "923000000000", before, 1)
+				if err != nil {
+					t.Fatalf("ListChats: %v", err)
+				}
+				if len(chats) == 0 {
+					break
+				}
+				got = append(got, chats[0].ID)
+				before = Cursor{Timestamp: chats[0].LastMessageAt, ID: chats[0].ID}
+			}
+			if len(got) != 4 {
+				t.Errorf("paged chats = %v, want all 4", got)
+			}
+		})
+	}
+}
+
+func TestStorePruneDropsEmptyChats(t *testing.T) {
+	ctx := context.Background()
+	const oldChat, newChat = "923222222222@s.whatsapp.net", "923333333333@s.whatsapp.net"
+	old := time.Now().Add(-48 * time.Hour).UnixMilli()
+	recent := time.Now().Add(-time.Minute).UnixMilli()
+
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s.SaveMessage(ctx, textMessage(oldChat, "OLD1", old))
+			s.SaveMessage(ctx, textMessage(oldChat, "OLD2", old+1))
+			for i := 0; i < 3; i++ {
+				s.SaveMessage(ctx, textMessage(newChat, fmt.Sprintf("NEW%d", i), recent+int64(i)))
+			}
+
+			removed, err := s.PruneMessages(ctx, 24*time.Hour, 2)
+			if err != nil {
+				t.Fatalf("PruneMessages: %v", err)
+			}
+			if removed != 3 {
+				t.Errorf("removed %d messages, want 3", removed)
+			}
+			if ok, _ := s.HasChat(ctx, "923000000000", oldChat); ok {
+				t.Error("emptied chat is still listed")
+			}
+			msgs, _ := s.ListMessages(ctx, "923000000000", newChat, Cursor{}, 10)
+			if len(msgs) != 2 || msgs[0].MessageID != "NEW1" {
+				t.Errorf("kept %v, want the newest 2", msgs)
+			}
+		})
+	}
+}
+
+// Bots in the same group archive the same message ID; each must get its own copy
+func TestStoreGetMessageByBot(t *testing.T) {
+	ctx := context.Background()
+	const chat = "120363000000000000@g.us"
+	ts := time.Now().Add(-time.Minute).UnixMilli()
+
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			mine, other := textMessage(chat, "SHARED", ts), textMessage(chat, "SHARED", ts+1)
+			other.BotID, other.Content = "923999999999", "other bot"
+			s.SaveMessage(ctx, mine)
+			s.SaveMessage(ctx, other)
+
+			got, err := s.GetMessage(ctx, mine.BotID, "SHARED")
+			if err != nil || got == nil || got.BotID != mine.BotID || got.Content != mine.Content {
+				t.Errorf("GetMessage(%s) = %+v, %v; want the bot's own copy", mine.BotID, got, err)
+			}
+			if got, _ := s.GetMessage(ctx, "923888888888", "SHARED"); got != nil {
+				t.Errorf("GetMessage for a bot without the message = %+v, want nil", got)
+			}
+		})
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Cursor
+		wantErr bool
+	}{
+		{raw: "1700000000000:3EB0ABC", want: Cursor{Timestamp: 1700000000000, ID: "3EB0ABC"}},
+		{raw: "1700000000000:120363@g.us", want: Cursor{Timestamp: 1700000000000, ID: "120363@g.us"}},
+		{raw: "1700000000000", want: Cursor{Timestamp: 1700000000000}},
+		{raw: "abc", wantErr: true},
+		{raw: "0:x", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCursor(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseCursor(%q) = %+v, %v; want %+v, err=%v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil {
+			if back, _ := parseCursor(got.String()); back != got {
+				t.Errorf("round trip of %q gave %+v", tt.raw, back)
+			}
+		}
+	}
+}
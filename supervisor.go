@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// 🛡️ CONNECTION SUPERVISOR (per bot)
+// ==========================================
+
+type ConnState string
+
+const (
+	ConnConnecting   ConnState = "connecting"
+	ConnConnected    ConnState = "connected"
+	ConnReconnecting ConnState = "reconnecting"
+	ConnLoggedOut    ConnState = "logged_out"
+	ConnReplaced     ConnState = "stream_replaced"
+	ConnBanned       ConnState = "temp_banned"
+	ConnStopped      ConnState = "stopped"
+)
+
+// Backoff: 2s, 4s, 8s ... capped at 5m, ±20% jitter
+const (
+	ReconnectBaseDelay = 2 * time.Second
+	ReconnectMaxDelay  = 5 * time.Minute
+	ReconnectJitter    = 0.2
+)
+
+// ConnStatus is what the API and /ws see of a supervisor
+type ConnStatus struct {
+	BotID     string    `json:"bot_id"`
+	State     ConnState `json:"state"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	Since     time.Time `json:"since"`
+	RetryAt   time.Time `json:"retry_at,omitempty"`
+}
+
+// BotSupervisor: ایک بوٹ کا کنکشن سنبھالنے والا
+type BotSupervisor struct {
+	ConnStatus // guarded by mu
+
+	client *whatsmeow.Client
+	ctx    context.Context // bot lifecycle, see SessionManager
+	wake   chan time.Duration
+	mu     sync.Mutex
+}
+
+var (
+	supervisors  = make(map[string]*BotSupervisor)
+	supervisorMu sync.Mutex
+)
+
+// superviseBot takes over reconnects for client until ctx (the bot lifecycle) ends
+func superviseBot(ctx context.Context, client *whatsmeow.Client, botID string) *BotSupervisor {
+	client.EnableAutoReconnect = false
+
+	sup := &BotSupervisor{
+		ConnStatus: ConnStatus{BotID: botID, State: ConnConnecting, Since: time.Now()},
+		client:     client,
+		ctx:        ctx,
+		wake:       make(chan time.Duration, 1),
+	}
+	client.AddEventHandler(sup.handleEvent)
+
+	supervisorMu.Lock()
+	supervisors[botID] = sup
+	supervisorMu.Unlock()
+
+	// Already-live clients (fresh pairing) skip the initial connect
+	if client.IsConnected() {
+		sup.State = ConnConnected
+	} else {
+		sup.scheduleReconnect(-1)
+	}
+	go sup.loop()
+	return sup
+}
+
+func getSupervisor(botID string) *BotSupervisor {
+	supervisorMu.Lock()
+	defer supervisorMu.Unlock()
+	return supervisors[botID]
+}
+
+func (s *BotSupervisor) Snapshot() ConnStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ConnStatus
+}
+
+func (s *BotSupervisor) stopped() bool {
+	return s.ctx.Err() != nil
+}
+
+// finish marks the supervisor stopped and forgets it if it is still current
+func (s *BotSupervisor) finish() {
+	s.setState(ConnStopped, "")
+	supervisorMu.Lock()
+	if supervisors[s.BotID] == s {
+		delete(supervisors, s.BotID)
+	}
+	supervisorMu.Unlock()
+}
+
+func (s *BotSupervisor) setState(state ConnState, errMsg string) {
+	s.mu.Lock()
+	if s.State == state && s.LastError == errMsg {
+		s.mu.Unlock()
+		return
+	}
+	// Once stopped, nothing else is reported
+	if s.State == ConnStopped {
+		s.mu.Unlock()
+		return
+	}
+	s.State = state
+	s.LastError = errMsg
+	s.Since = time.Now()
+	if state == ConnConnected {
+		s.Attempts = 0
+		s.RetryAt = time.Time{}
+	}
+	s.mu.Unlock()
+
+	fmt.Printf("📶 [%s] %s %s\n", s.BotID, state, errMsg)
+	broadcastWS(WSMessage{Type: "bot_state", BotID: s.BotID, Payload: s.Snapshot()})
+}
+
+// scheduleReconnect wakes the loop after delay (0 = use backoff)
+func (s *BotSupervisor) scheduleReconnect(delay time.Duration) {
+	select {
+	case s.wake <- delay:
+	default:
+	}
+}
+
+func (s *BotSupervisor) handleEvent(evt interface{}) {
+	if s.stopped() {
+		return
+	}
+	switch v := evt.(type) {
+	case *events.Connected:
+		s.setState(ConnConnected, "")
+
+	case *events.Disconnected:
+		s.setState(ConnReconnecting, "disconnected")
+		s.scheduleReconnect(0)
+
+	case *events.ConnectFailure:
+		s.setState(ConnReconnecting, fmt.Sprintf("connect failure: %s", v.Reason))
+		s.scheduleReconnect(0)
+
+	case *events.StreamReplaced:
+		// Another instance took over this device, reconnecting would fight it
+		s.setState(ConnReplaced, "stream replaced")
+
+	case *events.TemporaryBan:
+		s.mu.Lock()
+		s.RetryAt = time.Now().Add(v.Expire)
+		s.mu.Unlock()
+		s.setState(ConnBanned, v.String())
+		s.scheduleReconnect(v.Expire)
+
+	case *events.LoggedOut:
+		s.setState(ConnLoggedOut, fmt.Sprintf("logged out: %s", v.Reason))
+		go s.removeLoggedOut()
+	}
+}
+
+// loop performs reconnects whenever it is woken (delay < 0 = connect now)
+func (s *BotSupervisor) loop() {
+	defer s.finish()
+	for {
+		var delay time.Duration
+		select {
+		case <-s.ctx.Done():
+			return
+		case delay = <-s.wake:
+		}
+
+		if delay == 0 {
+			delay = s.nextBackoff()
+		}
+		if delay > 0 {
+			s.mu.Lock()
+			s.RetryAt = time.Now().Add(delay)
+			s.mu.Unlock()
+			timer := time.NewTimer(delay)
+			select {
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+
+		if s.stopped() {
+			return
+		}
+		if s.client.IsConnected() {
+			continue
+		}
+		if err := s.client.Connect(); err != nil {
+			s.setState(ConnReconnecting, err.Error())
+			s.scheduleReconnect(0)
+			continue
+		}
+	}
+}
+
+func (s *BotSupervisor) nextBackoff() time.Duration {
+	s.mu.Lock()
+	attempt := s.Attempts
+	s.Attempts++
+	s.mu.Unlock()
+
+	delay := ReconnectBaseDelay << uint(attempt)
+	if delay <= 0 || delay > ReconnectMaxDelay {
+		delay = ReconnectMaxDelay
+	}
+	jitter := 1 + ReconnectJitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * jitter)
+}
+
+// removeLoggedOut drops a session that was unlinked from the phone
+func (s *BotSupervisor) removeLoggedOut() {
+	sm.mu.RLock()
+	current := sm.Clients[s.BotID] == s.client
+	sm.mu.RUnlock()
+	if !current {
+		s.client.Disconnect()
+		return
+	}
+	// Chats stay readable in the inbox after a remote logout
+	if _, err := sm.Remove(s.BotID, RemoveOptions{KeepArchive: true}); err != nil {
+		fmt.Printf("⚠️ Cleanup after logout failed for %s: %v\n", s.BotID, err)
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ==========================================
+// 🏢 TENANTS (ہر کسٹمر کے اپنے بوٹس)
+// ==========================================
+
+// Every bot belongs to exactly one tenant. Bots paired before tenants
+// existed (or by the env admin) fall into DefaultTenant.
+const (
+	DefaultTenant    = "default"
+	bucketBotTenants = "bot_tenants"
+)
+
+var (
+	botTenants = make(map[string]string)
+	tenantMu   sync.RWMutex
+
+	tenantNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,31}$`)
+)
+
+func InitTenants() {
+	all, err := dataStore.List(context.Background(), bucketBotTenants)
+	if err != nil {
+		fmt.Printf("⚠️ Tenant Load Failed: %v\n", err)
+		return
+	}
+	tenantMu.Lock()
+	for botID, raw := range all {
+		botTenants[botID] = string(raw)
+	}
+	tenantMu.Unlock()
+	fmt.Printf("🏢 Loaded %d bot owners.\n", len(all))
+}
+
+// botTenant returns the owner of botID (DefaultTenant when unassigned)
+func botTenant(botID string) string {
+	tenantMu.RLock()
+	defer tenantMu.RUnlock()
+	if t, ok := botTenants[botID]; ok {
+		return t
+	}
+	return DefaultTenant
+}
+
+// botOwned reports whether botID was ever assigned to a tenant
+func botOwned(botID string) (string, bool) {
+	tenantMu.RLock()
+	defer tenantMu.RUnlock()
+	t, ok := botTenants[botID]
+	return t, ok
+}
+
+// ownedByOther: botID belongs to some other tenant. Live bots without a
+// record count as DefaultTenant so legacy sessions cannot be taken over.
+func ownedByOther(botID, tenant string) bool {
+	owner, ok := botOwned(botID)
+	if !ok {
+		sm.mu.RLock()
+		_, live := sm.Clients[botID]
+		sm.mu.RUnlock()
+		owner, ok = DefaultTenant, live
+	}
+	return ok && owner != tenant
+}
+
+func setBotTenant(botID, tenant string) error {
+	tenantMu.Lock()
+	botTenants[botID] = tenant
+	tenantMu.Unlock()
+	return dataStore.Put(context.Background(), bucketBotTenants, botID, []byte(tenant))
+}
+
+func forgetBotTenant(botID string) {
+	tenantMu.Lock()
+	delete(botTenants, botID)
+	tenantMu.Unlock()
+	dataStore.Delete(context.Background(), bucketBotTenants, botID)
+}
+
+// tenantBots lists the live bots of tenant, sorted
+func tenantBots(tenant string) []string {
+	sm.mu.RLock()
+	ids := make([]string, 0, len(sm.Clients))
+	for id := range sm.Clients {
+		if botTenant(id) == tenant {
+			ids = append(ids, id)
+		}
+	}
+	sm.mu.RUnlock()
+	sort.Strings(ids)
+	return ids
+}
+
+// CanAccessBot: platform admins see everything, others only their tenant
+func (p *Principal) CanAccessBot(botID string) bool {
+	return p != nil && (p.Admin || botTenant(botID) == p.Tenant)
+}
+
+func (p *Principal) CanAccessTenant(tenant string) bool {
+	return p != nil && (p.Admin || tenant == p.Tenant)
+}
+
+// ==========================================
+// 🌐 TENANT ADMIN API (platform admin only)
+// ==========================================
+
+func setupTenantRoutes() {
+	http.HandleFunc("GET /api/tenants", handleListTenants)
+	http.HandleFunc("POST /api/tenants", handleCreateTenantUser)
+	http.HandleFunc("DELETE /api/tenants/users/{username}", handleDeleteTenantUser)
+	http.HandleFunc("PUT /api/sessions/{id}/tenant", handleAssignBotTenant)
+}
+
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if p := principalFrom(r); p == nil || !p.Admin {
+		writeError(w, http.StatusForbidden, "Admin only")
+		return false
+	}
+	return true
+}
+
+type tenantInfo struct {
+	Tenant string   `json:"tenant"`
+	Users  []string `json:"users"`
+	Bots   []string `json:"bots"`
+}
+
+// GET /api/tenants
+func handleListTenants(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	all, err := dataStore.List(r.Context(), bucketAuthUsers)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load users")
+		return
+	}
+	byTenant := make(map[string]*tenantInfo)
+	get := func(t string) *tenantInfo {
+		if byTenant[t] == nil {
+			byTenant[t] = &tenantInfo{Tenant: t, Users: []string{}, Bots: tenantBots(t)}
+		}
+		return byTenant[t]
+	}
+	get(DefaultTenant)
+	for _, raw := range all {
+		var u AuthUser
+		if json.Unmarshal(raw, &u) == nil {
+			info := get(u.tenant())
+			info.Users = append(info.Users, u.Username)
+		}
+	}
+
+	out := make([]*tenantInfo, 0, len(byTenant))
+	for _, info := range byTenant {
+		sort.Strings(info.Users)
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tenant < out[j].Tenant })
+	writeJSON(w, http.StatusOK, out)
+}
+
+// POST /api/tenants {"username","password","tenant"} -> operator account (tenant defaults to username)
+func handleCreateTenantUser(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Tenant   string `json:"tenant"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+	req.Username = strings.ToLower(strings.TrimSpace(req.Username))
+	req.Tenant = strings.ToLower(strings.TrimSpace(req.Tenant))
+	if req.Tenant == "" {
+		req.Tenant = req.Username
+	}
+	if !tenantNameRe.MatchString(req.Username) || !tenantNameRe.MatchString(req.Tenant) {
+		writeError(w, http.StatusBadRequest, "Username/tenant must be 2-32 chars: a-z 0-9 _ -")
+		return
+	}
+	if len(req.Password) < 8 {
+		writeError(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+	if found, _ := getJSON(bucketAuthUsers, req.Username, &AuthUser{}); found || req.Username == adminUser {
+		writeError(w, http.StatusConflict, "User already exists")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Password hashing failed")
+		return
+	}
+	u := AuthUser{Username: req.Username, PasswordHash: string(hash), Tenant: req.Tenant, CreatedAt: time.Now()}
+	if err := putJSON(bucketAuthUsers, u.Username, u); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to store user")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"username": u.Username, "tenant": u.Tenant})
+}
+
+// DELETE /api/tenants/users/{username}
+func handleDeleteTenantUser(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	username := strings.ToLower(r.PathValue("username"))
+	if username == adminUser {
+		writeError(w, http.StatusBadRequest, "Cannot delete the env admin")
+		return
+	}
+	if found, _ := getJSON(bucketAuthUsers, username, &AuthUser{}); !found {
+		writeError(w, http.StatusNotFound, "Unknown user")
+		return
+	}
+	dataStore.Delete(r.Context(), bucketAuthUsers, username)
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": username})
+}
+
+// PUT /api/sessions/{id}/tenant {"tenant"} -> move a bot to another tenant
+func handleAssignBotTenant(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	botID := getCleanID(r.PathValue("id"))
+	var req struct {
+		Tenant string `json:"tenant"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+	tenant := strings.ToLower(strings.TrimSpace(req.Tenant))
+	if !tenantNameRe.MatchString(tenant) {
+		writeError(w, http.StatusBadRequest, "Invalid tenant")
+		return
+	}
+	if err := setBotTenant(botID, tenant); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to assign tenant")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"bot_id": botID, "tenant": tenant})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"sync"
 	"go.mau.fi/whatsmeow"
 )
@@ -17,8 +18,50 @@ type BotSettings struct {
 	StatusReact  bool   `json:"status_react"`
 	AlwaysOnline bool   `json:"always_online"`
 	Prefix       string `json:"prefix"`
-	Mode         string `json:"mode"`
+	Mode         string `json:"mode"` // public, admin or private (see botMode)
 	WelcomeMsg   bool   `json:"welcome_msg"`
+
+	// Extra owners (canonical phone identities); the bot's own number is always owner
+	Owners []string `json:"owners,omitempty"`
+
+	// Menu look (see brand.go)
+	Branding Branding `json:"branding"`
+
+	// Extra prefixes, no-prefix commands and @mention (see prefix.go)
+	PrefixRules   PrefixConfig             `json:"prefix_rules"`
+	GroupPrefixes map[string]*PrefixConfig `json:"group_prefixes,omitempty"`
+
+	// Per-group mode overrides (group JID -> mode)
+	GroupModes map[string]string `json:"group_modes,omitempty"`
+}
+
+// clone copies s deep enough to be saved without holding sm.mu
+func (s *BotSettings) clone() BotSettings {
+	c := *s
+	c.Owners = append([]string(nil), s.Owners...)
+	c.PrefixRules = s.PrefixRules.clone()
+	if s.GroupPrefixes != nil {
+		c.GroupPrefixes = make(map[string]*PrefixConfig, len(s.GroupPrefixes))
+		for chat, g := range s.GroupPrefixes {
+			gc := g.clone()
+			c.GroupPrefixes[chat] = &gc
+		}
+	}
+	if s.GroupModes != nil {
+		c.GroupModes = make(map[string]string, len(s.GroupModes))
+		for chat, m := range s.GroupModes {
+			c.GroupModes[chat] = m
+		}
+	}
+	return c
+}
+
+func (p PrefixConfig) clone() PrefixConfig {
+	c := p
+	if p.Prefixes != nil { c.Prefixes = append([]string{}, p.Prefixes...) }
+	if p.NoPrefix != nil { c.NoPrefix = append([]string{}, p.NoPrefix...) }
+	if p.Mention != nil { m := *p.Mention; c.Mention = &m }
+	return c
 }
 
 // 2. SessionManager: تمام بوٹس اور ان کا ڈیٹا سنبھالنے والا
@@ -28,6 +71,9 @@ type SessionManager struct {
 	
 	// Settings (RAM میں موجود سیٹنگز)
 	Settings map[string]*BotSettings
+
+	// Per-bot lifecycle: cancel روکنے پر presence/reconnect loops بند ہو جاتے ہیں
+	lifecycles map[string]context.CancelFunc
 	
 	// Mutex (تاکہ ایک وقت میں دو پروسیس ڈیٹا خراب نہ کریں)
 	mu sync.RWMutex
@@ -39,9 +85,15 @@ type WSMessage struct {
 	ActiveBots int         `json:"active_bots,omitempty"`
 	BotID      string      `json:"bot_id,omitempty"`
 	Payload    interface{} `json:"payload,omitempty"`
+
+	// Tenant limits delivery; empty = owner of BotID (see broadcastWS)
+	Tenant string `json:"-"`
 }
 
-// 4. Pair Request
+// 4. Pair Request (Mode: "code" = phone-number code, "qr" = scan QR)
 type PairRequest struct {
-	Number string `json:"number"`
+	Number      string `json:"number"`
+	Mode        string `json:"mode,omitempty"`
+	ClientType  string `json:"client_type,omitempty"`  // chrome, firefox, edge, safari...
+	DisplayName string `json:"display_name,omitempty"` // "Browser (OS)"
 }
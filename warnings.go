@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ==========================================
+// ⚠️ WARNINGS (per bot / group / user)
+// ==========================================
+
+// One record per group holds the policy, every user's warnings, the
+// temporary bans and an audit log of who did what.
+
+type Warning struct {
+	Reason string    `json:"reason"`
+	By     string    `json:"by"` // identity of the issuer, "auto" for moderation
+	At     time.Time `json:"at"`
+}
+
+type GroupBan struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+}
+
+type WarnAudit struct {
+	At     time.Time `json:"at"`
+	Action string    `json:"action"` // warn, reset, kick, ban, kick-failed, ban-failed, rejoin-kick
+	Target string    `json:"target"`
+	By     string    `json:"by"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// WarnPolicy: zero values mean the defaults below
+type WarnPolicy struct {
+	Limit      int    `json:"limit,omitempty"`
+	Penalty    string `json:"penalty,omitempty"` // kick | ban
+	BanHours   int    `json:"ban_hours,omitempty"`
+	ExpireDays int    `json:"expire_days,omitempty"`
+}
+
+type GroupWarnings struct {
+	Policy WarnPolicy           `json:"policy"`
+	Users  map[string][]Warning `json:"users,omitempty"` // identity -> warnings
+	Bans   map[string]GroupBan  `json:"bans,omitempty"`
+	Log    []WarnAudit          `json:"log,omitempty"`
+}
+
+const (
+	bucketWarnings = "warnings"
+	MaxWarnAudit   = 200
+
+	DefaultWarnLimit      = 3
+	DefaultWarnPenalty    = "kick"
+	DefaultWarnBanHours   = 24
+	DefaultWarnExpireDays = 30
+)
+
+var warnMutex sync.Mutex // serializes read-modify-write of group records
+
+func (p WarnPolicy) limit() int      { return orDefault(p.Limit, DefaultWarnLimit) }
+func (p WarnPolicy) banHours() int   { return orDefault(p.BanHours, DefaultWarnBanHours) }
+func (p WarnPolicy) expireDays() int { return orDefault(p.ExpireDays, DefaultWarnExpireDays) }
+
+func (p WarnPolicy) penalty() string {
+	if p.Penalty == "" {
+		return DefaultWarnPenalty
+	}
+	return p.Penalty
+}
+
+func orDefault(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+	return n
+}
+
+func warnKey(botID, chat string) string { return botID + "|" + chat }
+
+// updateGroupWarnings loads, prunes expired entries, applies fn and saves
+func updateGroupWarnings(botID, chat string, fn func(g *GroupWarnings)) (*GroupWarnings, error) {
+	warnMutex.Lock()
+	defer warnMutex.Unlock()
+	g := &GroupWarnings{}
+	if _, err := getJSON(bucketWarnings, warnKey(botID, chat), g); err != nil {
+		return nil, err
+	}
+	if g.Users == nil { g.Users = make(map[string][]Warning) }
+	if g.Bans == nil { g.Bans = make(map[string]GroupBan) }
+	g.prune()
+	if fn != nil { fn(g) }
+	if len(g.Log) > MaxWarnAudit { g.Log = g.Log[len(g.Log)-MaxWarnAudit:] }
+	return g, putJSON(bucketWarnings, warnKey(botID, chat), g)
+}
+
+// loadGroupWarnings reads chat's warnings. On error callers must fail closed:
+// an empty result would let banned users back in.
+func loadGroupWarnings(botID, chat string) (*GroupWarnings, error) {
+	warnMutex.Lock()
+	defer warnMutex.Unlock()
+	g := &GroupWarnings{}
+	if _, err := getJSON(bucketWarnings, warnKey(botID, chat), g); err != nil {
+		return nil, err
+	}
+	g.prune()
+	return g, nil
+}
+
+// prune drops warnings past the expiry and finished bans
+func (g *GroupWarnings) prune() {
+	cutoff := time.Now().AddDate(0, 0, -g.Policy.expireDays())
+	for id, list := range g.Users {
+		kept := list[:0]
+		for _, w := range list {
+			if w.At.After(cutoff) { kept = append(kept, w) }
+		}
+		if len(kept) == 0 {
+			delete(g.Users, id)
+		} else {
+			g.Users[id] = kept
+		}
+	}
+	for id, b := range g.Bans {
+		if time.Now().After(b.Until) { delete(g.Bans, id) }
+	}
+}
+
+func (g *GroupWarnings) audit(action, target, by, reason string) {
+	g.Log = append(g.Log, WarnAudit{At: time.Now(), Action: action, Target: target, By: by, Reason: reason})
+}
+
+// groupBan reports whether id is currently banned from chat
+func groupBan(botID, chat, id string) (GroupBan, bool, error) {
+	g, err := loadGroupWarnings(botID, chat)
+	if err != nil {
+		return GroupBan{}, false, err
+	}
+	b, ok := g.Bans[id]
+	return b, ok, nil
+}
+
+// issueWarning records a warning and applies the penalty once the limit is reached.
+// by is the issuer's identity ("auto" for the moderation engine).
+func issueWarning(client *whatsmeow.Client, chat, target types.JID, by, reason string) {
+	botID := getCleanID(client.Store.ID.User)
+	id := resolveIdentity(client, target)
+	if reason == "" { reason = "no reason given" }
+
+	var count, limit int
+	var penalty string
+	var banHours int
+	_, err := updateGroupWarnings(botID, chat.String(), func(g *GroupWarnings) {
+		g.Users[id] = append(g.Users[id], Warning{Reason: reason, By: by, At: time.Now()})
+		g.audit("warn", id, by, reason)
+		count, limit, penalty, banHours = len(g.Users[id]), g.Policy.limit(), g.Policy.penalty(), g.Policy.banHours()
+		if count < limit {
+			return
+		}
+		// Limit reached: the slate is wiped and the ban recorded up front so a
+		// quick rejoin is caught; the kick itself is logged once it happened
+		delete(g.Users, id)
+		if penalty == "ban" {
+			g.Bans[id] = GroupBan{Until: time.Now().Add(time.Duration(banHours) * time.Hour), Reason: reason}
+		}
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Warning Save Failed (%s): %v\n", botID, err)
+		return
+	}
+
+	if count < limit {
+		sendMention(client, chat, fmt.Sprintf("⚠️ @%s warned (%d/%d)\n📝 %s", target.User, count, limit, reason), []types.JID{target})
+		return
+	}
+
+	text := fmt.Sprintf("👢 @%s reached %d warnings and was removed.", target.User, limit)
+	if penalty == "ban" {
+		text = fmt.Sprintf("⛔ @%s reached %d warnings: removed and banned for %dh.", target.User, limit, banHours)
+	}
+	action, note := penalty, fmt.Sprintf("%d warnings", count)
+	if _, err := client.UpdateGroupParticipants(context.Background(), chat, []types.JID{target}, whatsmeow.ParticipantChangeRemove); err != nil {
+		text = fmt.Sprintf("⚠️ @%s reached %d warnings but I couldn't remove them (am I admin?).", target.User, limit)
+		action, note = penalty+"-failed", err.Error()
+	}
+	if _, err := updateGroupWarnings(botID, chat.String(), func(g *GroupWarnings) { g.audit(action, id, by, note) }); err != nil {
+		fmt.Printf("⚠️ Warning Save Failed (%s): %v\n", botID, err)
+	}
+	sendMention(client, chat, text, []types.JID{target})
+}
+
+// enforceGroupBans removes banned users who were re-added or rejoined
+func enforceGroupBans(client *whatsmeow.Client, evt *events.GroupInfo) {
+	if len(evt.Join) == 0 {
+		return
+	}
+	botID := getCleanID(client.Store.ID.User)
+	g, err := loadGroupWarnings(botID, evt.JID.String())
+	if err != nil {
+		fmt.Printf("⚠️ Ban check failed (%s): %v\n", evt.JID, err)
+		return
+	}
+	if len(g.Bans) == 0 {
+		return
+	}
+	for _, jid := range evt.Join {
+		id := resolveIdentity(client, jid)
+		ban, banned := g.Bans[id]
+		if !banned {
+			continue
+		}
+		if _, err := client.UpdateGroupParticipants(context.Background(), evt.JID, []types.JID{jid}, whatsmeow.ParticipantChangeRemove); err != nil {
+			fmt.Printf("⚠️ Ban enforce failed (%s): %v\n", id, err)
+			continue
+		}
+		updateGroupWarnings(botID, evt.JID.String(), func(g *GroupWarnings) { g.audit("rejoin-kick", id, "auto", ban.Reason) })
+		sendMention(client, evt.JID, fmt.Sprintf("⛔ @%s is banned here until %s.", jid.User, ban.Until.Format("02 Jan 15:04")), []types.JID{jid})
+	}
+}
+
+// ==========================================
+// 🛠️ WARN COMMANDS
+// ==========================================
+
+// warnTarget resolves the user with GetTarget; what follows the @mention/number is the reason
+func warnTarget(c *CmdContext) (types.JID, string, bool) {
+	var first []string
+	if len(c.Args) > 0 { first = c.Args[:1] }
+	target, ok := GetTarget(c.Msg, first)
+	rest := c.FullArgs
+	if len(c.Args) > 0 && looksLikeJID(c.Args[0]) {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, c.Args[0]))
+	}
+	return target, rest, ok
+}
+
+// HandleWarn: .warn @user [reason]
+func HandleWarn(c *CmdContext) {
+	target, reason, ok := warnTarget(c)
+	if !ok {
+		c.Reply(fmt.Sprintf("⚠️ Usage: %swarn @user [reason]", c.Prefix))
+		return
+	}
+	id := resolveIdentity(c.Client, target)
+	if id == c.BotID || isBotOwner(c.Client, target) || isGroupAdmin(c.Client, c.Msg.Info.Chat, target) {
+		c.Reply("🚫 Admins, owners and the bot can't be warned.")
+		return
+	}
+	issueWarning(c.Client, c.Msg.Info.Chat, target, senderIdentity(c.Client, c.Msg), reason)
+}
+
+// HandleWarns: .warns [@user]
+func HandleWarns(c *CmdContext) {
+	g, err := loadGroupWarnings(c.BotID, c.Msg.Info.Chat.String())
+	if err != nil {
+		c.Reply("❌ Load failed: " + err.Error())
+		return
+	}
+	target, _, ok := warnTarget(c)
+	limit := g.Policy.limit()
+
+	if !ok {
+		if len(g.Users) == 0 && len(g.Bans) == 0 {
+			c.Reply("✅ No active warnings in this group.")
+			return
+		}
+		ids := make([]string, 0, len(g.Users))
+		for id := range g.Users { ids = append(ids, id) }
+		sort.Slice(ids, func(i, j int) bool { return len(g.Users[ids[i]]) > len(g.Users[ids[j]]) })
+		var sb strings.Builder
+		sb.WriteString("⚠️ *Warnings*\n\n")
+		for _, id := range ids {
+			sb.WriteString(fmt.Sprintf("• %s — %d/%d\n", id, len(g.Users[id]), limit))
+		}
+		for id, b := range g.Bans {
+			sb.WriteString(fmt.Sprintf("⛔ %s — banned until %s\n", id, b.Until.Format("02 Jan 15:04")))
+		}
+		c.Reply(strings.TrimSpace(sb.String()))
+		return
+	}
+
+	id := resolveIdentity(c.Client, target)
+	list := g.Users[id]
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚠️ *%s* — %d/%d warnings\n", id, len(list), limit))
+	for i, w := range list {
+		sb.WriteString(fmt.Sprintf("\n%d. %s\n    👮 %s • %s", i+1, w.Reason, w.By, w.At.Format("02 Jan 15:04")))
+	}
+	if b, banned := g.Bans[id]; banned {
+		sb.WriteString(fmt.Sprintf("\n\n⛔ Banned until %s", b.Until.Format("02 Jan 15:04")))
+	}
+	c.Reply(sb.String())
+}
+
+// HandleResetWarn: .resetwarn @user (also lifts a ban)
+func HandleResetWarn(c *CmdContext) {
+	target, _, ok := warnTarget(c)
+	if !ok {
+		c.Reply(fmt.Sprintf("⚠️ Usage: %sresetwarn @user", c.Prefix))
+		return
+	}
+	id := resolveIdentity(c.Client, target)
+	by := senderIdentity(c.Client, c.Msg)
+	cleared := 0
+	_, err := updateGroupWarnings(c.BotID, c.Msg.Info.Chat.String(), func(g *GroupWarnings) {
+		cleared = len(g.Users[id])
+		if _, banned := g.Bans[id]; banned { cleared++ }
+		delete(g.Users, id)
+		delete(g.Bans, id)
+		g.audit("reset", id, by, "")
+	})
+	if err != nil {
+		c.Reply("❌ Save failed: " + err.Error())
+		return
+	}
+	if cleared == 0 {
+		c.Reply("ℹ️ " + id + " had no warnings.")
+		return
+	}
+	c.Reply("♻️ Warnings cleared for " + id)
+}
+
+// HandleWarnSet: .warnset limit <n> | penalty kick|ban | bantime <hours> | expire <days>
+func HandleWarnSet(c *CmdContext) {
+	key, value := strings.ToLower(c.Str("key")), strings.ToLower(c.Str("value"))
+	n, numErr := strconv.Atoi(value)
+	var apply func(p *WarnPolicy)
+	switch {
+	case key == "penalty" && (value == "kick" || value == "ban"):
+		apply = func(p *WarnPolicy) { p.Penalty = value }
+	case key == "limit" && numErr == nil && n >= 1 && n <= 20:
+		apply = func(p *WarnPolicy) { p.Limit = n }
+	case key == "bantime" && numErr == nil && n >= 1 && n <= 24*30:
+		apply = func(p *WarnPolicy) { p.BanHours = n }
+	case key == "expire" && numErr == nil && n >= 1 && n <= 365:
+		apply = func(p *WarnPolicy) { p.ExpireDays = n }
+	}
+	if apply == nil {
+		g, err := loadGroupWarnings(c.BotID, c.Msg.Info.Chat.String())
+		if err != nil {
+			c.Reply("❌ Load failed: " + err.Error())
+			return
+		}
+		p := g.Policy
+		c.Reply(fmt.Sprintf("⚠️ *Warn policy*\n\n🔢 Limit: %d\n⚖️ Penalty: %s\n⛔ Ban: %dh\n⌛ Expire: %d days\n\n%swarnset limit <1-20> | penalty kick|ban | bantime <hours> | expire <days>",
+			p.limit(), p.penalty(), p.banHours(), p.expireDays(), c.Prefix))
+		return
+	}
+	by := senderIdentity(c.Client, c.Msg)
+	if _, err := updateGroupWarnings(c.BotID, c.Msg.Info.Chat.String(), func(g *GroupWarnings) {
+		apply(&g.Policy)
+		g.audit("policy", key, by, value)
+	}); err != nil {
+		c.Reply("❌ Save failed: " + err.Error())
+		return
+	}
+	c.Reply(fmt.Sprintf("✅ %s = %s", key, value))
+}
+
+// HandleWarnLog: .warnlog -> last audit entries
+func HandleWarnLog(c *CmdContext) {
+	g, err := loadGroupWarnings(c.BotID, c.Msg.Info.Chat.String())
+	if err != nil {
+		c.Reply("❌ Load failed: " + err.Error())
+		return
+	}
+	if len(g.Log) == 0 {
+		c.Reply("📜 Audit log is empty.")
+		return
+	}
+	start := max(0, len(g.Log)-15)
+	var sb strings.Builder
+	sb.WriteString("📜 *Warn log*\n")
+	for i := len(g.Log) - 1; i >= start; i-- {
+		e := g.Log[i]
+		sb.WriteString(fmt.Sprintf("\n%s • *%s* %s by %s", e.At.Format("02 Jan 15:04"), e.Action, e.Target, e.By))
+		if e.Reason != "" { sb.WriteString(" — " + e.Reason) }
+	}
+	c.Reply(sb.String())
+}